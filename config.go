@@ -0,0 +1,324 @@
+/*
+Copyright 2026 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+this file except in compliance with the License. You may obtain a copy of the
+License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed
+under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+CONDITIONS OF ANY KIND, either express or implied. See the License for the
+specific language governing permissions and limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"time"
+
+	"sigs.k8s.io/yaml"
+
+	"github.com/crossplane/crossplane-runtime/v2/pkg/logging"
+	"github.com/crossplane/inspector-sidecar/server"
+)
+
+// A config is the optional configuration file. Settings in the file override
+// the corresponding flags. For example:
+//
+//	format: text
+//	sinks:
+//	- stdout
+//	- file,path=/var/log/inspector/archive.log,archive=true
+//	include:
+//	- composition=my-composition
+//	filter: eventType == "RESPONSE"
+//	maxPayloadBytes: 65536
+//	redaction:
+//	  rules:
+//	  - hash:desired.composite.resource.spec.apiKey
+//	sampling:
+//	  ratio: 0.1
+type config struct {
+	Format          *string          `json:"format,omitempty"`
+	Sinks           []string         `json:"sinks,omitempty"`
+	Include         []string         `json:"include,omitempty"`
+	Exclude         []string         `json:"exclude,omitempty"`
+	Filter          *string          `json:"filter,omitempty"`
+	MaxPayloadBytes *int             `json:"maxPayloadBytes,omitempty"`
+	Redaction       *redactionConfig `json:"redaction,omitempty"`
+	Sampling        *samplingConfig  `json:"sampling,omitempty"`
+}
+
+// A redactionConfig configures redaction. The redaction key is deliberately
+// only configurable using a flag or environment variable, so it can come from
+// a Secret.
+type redactionConfig struct {
+	Rules    []string `json:"rules,omitempty"`
+	Defaults *bool    `json:"defaults,omitempty"`
+}
+
+// A samplingConfig configures sampling.
+type samplingConfig struct {
+	Ratio      *float64 `json:"ratio,omitempty"`
+	Rate       *float64 `json:"rate,omitempty"`
+	Burst      *int     `json:"burst,omitempty"`
+	KeepErrors *bool    `json:"keepErrors,omitempty"`
+}
+
+// parseConfig parses the supplied configuration file. Unknown fields are
+// rejected, so typos don't silently change nothing.
+func parseConfig(data []byte) (*config, error) {
+	cfg := &config{}
+	if err := yaml.UnmarshalStrict(data, cfg); err != nil {
+		return nil, err
+	}
+	if cfg.Format != nil && !slices.Contains([]string{server.FormatJSON, server.FormatText, server.FormatSummary}, *cfg.Format) {
+		return nil, fmt.Errorf("invalid format %q: must be %s, %s or %s", *cfg.Format, server.FormatJSON, server.FormatText, server.FormatSummary)
+	}
+	return cfg, nil
+}
+
+// captureSettings are the settings that control which events are captured,
+// and how and where they're written. They can be changed by reloading the
+// configuration file.
+type captureSettings struct {
+	Format            string
+	Sinks             []string
+	Include           []string
+	Exclude           []string
+	Filter            string
+	MaxPayloadBytes   int
+	Redact            []string
+	RedactionKey      string
+	DefaultRedactions bool
+	SampleRatio       float64
+	SampleRate        float64
+	SampleBurst       int
+	SampleKeepErrors  bool
+}
+
+// captureSettings returns the capture settings configured by flags.
+func (c *ServeCmd) captureSettings() captureSettings {
+	return captureSettings{
+		Format:            c.Format,
+		Sinks:             c.Sinks,
+		Include:           c.Include,
+		Exclude:           c.Exclude,
+		Filter:            c.Filter,
+		MaxPayloadBytes:   c.MaxPayloadBytes,
+		Redact:            c.Redact,
+		RedactionKey:      c.RedactionKey,
+		DefaultRedactions: c.DefaultRedactions,
+		SampleRatio:       c.SampleRatio,
+		SampleRate:        c.SampleRate,
+		SampleBurst:       c.SampleBurst,
+		SampleKeepErrors:  c.SampleKeepErrors,
+	}
+}
+
+// apply returns the supplied settings, overridden by any settings in the
+// configuration file.
+func (cfg *config) apply(s captureSettings) captureSettings {
+	if cfg.Format != nil {
+		s.Format = *cfg.Format
+	}
+	if cfg.Sinks != nil {
+		s.Sinks = cfg.Sinks
+	}
+	if cfg.Include != nil {
+		s.Include = cfg.Include
+	}
+	if cfg.Exclude != nil {
+		s.Exclude = cfg.Exclude
+	}
+	if cfg.Filter != nil {
+		s.Filter = *cfg.Filter
+	}
+	if cfg.MaxPayloadBytes != nil {
+		s.MaxPayloadBytes = *cfg.MaxPayloadBytes
+	}
+	if r := cfg.Redaction; r != nil {
+		if r.Rules != nil {
+			s.Redact = r.Rules
+		}
+		if r.Defaults != nil {
+			s.DefaultRedactions = *r.Defaults
+		}
+	}
+	if sm := cfg.Sampling; sm != nil {
+		if sm.Ratio != nil {
+			s.SampleRatio = *sm.Ratio
+		}
+		if sm.Rate != nil {
+			s.SampleRate = *sm.Rate
+		}
+		if sm.Burst != nil {
+			s.SampleBurst = *sm.Burst
+		}
+		if sm.KeepErrors != nil {
+			s.SampleKeepErrors = *sm.KeepErrors
+		}
+	}
+	return s
+}
+
+// A capture is the part of the sidecar that's rebuilt when the configuration
+// file changes.
+type capture struct {
+	// sink writes events to all configured sinks.
+	sink server.Sink
+
+	// filter decides which events are captured.
+	filter server.Filter
+
+	// redactor redacts captured events.
+	redactor *server.Redactor
+
+	// sinks are the sinks built from each sink spec, keyed by spec.
+	sinks map[string]server.Sink
+}
+
+// buildCapture validates the supplied settings and builds a capture from them.
+// Sinks in the supplied reuse map, keyed by spec, are reused rather than
+// opened again. Events are also written to any supplied extra sinks, which are
+// never closed.
+func buildCapture(s captureSettings, deps sinkDeps, reuse map[string]server.Sink, extra ...server.Sink) (*capture, error) {
+	if s.SampleRatio < 0 || s.SampleRatio > 1 {
+		return nil, errors.New("sample ratio must be between 0 and 1")
+	}
+	filter, err := buildFilter(s.Include, s.Exclude)
+	if err != nil {
+		return nil, err
+	}
+	sampler := server.NewSampler(
+		server.WithSampleRatio(s.SampleRatio),
+		server.WithRateLimit(s.SampleRate, s.SampleBurst),
+		server.WithKeepErrors(s.SampleKeepErrors),
+	)
+
+	redactor, err := newRedactor(s.Redact, s.RedactionKey, s.DefaultRedactions)
+	if err != nil {
+		return nil, err
+	}
+
+	// Compile the filter before building sinks, so a bad expression fails
+	// fast.
+	var cel server.Filter
+	if s.Filter != "" {
+		if cel, err = server.NewCELFilter(s.Filter); err != nil {
+			return nil, fmt.Errorf("invalid filter: %w", err)
+		}
+	}
+
+	// Extra sinks outlive any one capture, so hide their Close methods.
+	shared := make([]server.Sink, len(extra))
+	for i, e := range extra {
+		shared[i] = server.SinkFunc(e.Write)
+	}
+	sink, sinks, err := buildSinks(s.Sinks, s.Format, s.MaxPayloadBytes, deps, reuse, shared...)
+	if err != nil {
+		return nil, err
+	}
+	if cel != nil {
+		sink = server.NewFilteredSink(sink, cel)
+	}
+
+	return &capture{
+		sink:     sink,
+		filter:   server.MatchAll(filter, sampler),
+		redactor: redactor,
+		sinks:    sinks,
+	}, nil
+}
+
+// A configReloader polls the configuration file, and swaps in a new capture
+// when it changes.
+type configReloader struct {
+	path  string
+	flags captureSettings
+	deps  sinkDeps
+	extra []server.Sink
+	log   logging.Logger
+
+	sink      *server.SwappableSink
+	filter    *server.SwappableFilter
+	redacting *server.RedactingSink
+
+	last  []byte
+	sinks map[string]server.Sink
+}
+
+// load reads, validates and builds the configuration file. It returns a nil
+// capture if the file hasn't changed since it was last loaded.
+func (r *configReloader) load() (*capture, error) {
+	data, err := os.ReadFile(r.path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read config file: %w", err)
+	}
+	if r.last != nil && bytes.Equal(data, r.last) {
+		return nil, nil //nolint:nilnil // A nil capture means nothing changed.
+	}
+	cfg, err := parseConfig(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %w", r.path, err)
+	}
+	c, err := buildCapture(cfg.apply(r.flags), r.deps, r.sinks, r.extra...)
+	if err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %w", r.path, err)
+	}
+	r.last = data
+	r.sinks = c.sinks
+	return c, nil
+}
+
+// reload the configuration file if it changed. The redactor, sinks and filter
+// are each swapped atomically, so every event is written by either the old or
+// the new sinks. Sinks whose spec didn't change are reused, so a file or
+// database is never opened twice. The other old sinks are closed once
+// in-flight writes finish.
+func (r *configReloader) reload() error {
+	prev := r.sinks
+	c, err := r.load()
+	if err != nil || c == nil {
+		return err
+	}
+	r.redacting.SetRedactor(c.redactor)
+	r.sink.Swap(c.sink)
+	r.filter.Swap(c.filter)
+	for key, s := range prev {
+		if _, ok := c.sinks[key]; ok {
+			continue
+		}
+		if err := server.CloseSink(s); err != nil {
+			r.log.Info("Cannot close previous sink", "error", err)
+		}
+	}
+	r.log.Info("Reloaded config file", "path", r.path)
+	return nil
+}
+
+// Run polls the configuration file at the supplied interval until the
+// supplied context is done. Invalid configuration is logged and ignored, and
+// the previous configuration stays in effect.
+func (r *configReloader) Run(ctx context.Context, interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			if err := r.reload(); err != nil {
+				r.log.Info("Cannot reload config file", "error", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,218 @@
+/*
+Copyright 2026 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+this file except in compliance with the License. You may obtain a copy of the
+License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed
+under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+CONDITIONS OF ANY KIND, either express or implied. See the License for the
+specific language governing permissions and limitations under the License.
+*/
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	pipelinev1alpha1 "github.com/crossplane/crossplane-runtime/v2/apis/pipelineinspector/proto/v1alpha1"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/logging"
+	"github.com/crossplane/inspector-sidecar/server"
+)
+
+func TestParseConfig(t *testing.T) {
+	flags := captureSettings{
+		Format:            "json",
+		Sinks:             []string{"stdout"},
+		DefaultRedactions: true,
+		SampleRatio:       1,
+		SampleBurst:       5,
+		SampleKeepErrors:  true,
+	}
+
+	tests := []struct {
+		name    string
+		config  string
+		want    captureSettings
+		wantErr bool
+	}{
+		{
+			name:   "empty config keeps flags",
+			config: "",
+			want:   flags,
+		},
+		{
+			name: "config overrides flags",
+			config: `
+format: text
+sinks:
+- stderr
+- file,path=/tmp/events.log,archive=true
+include:
+- composition=my-composition
+exclude:
+- function=function-auto-ready
+filter: eventType == "RESPONSE"
+maxPayloadBytes: 1024
+redaction:
+  rules:
+  - hash:desired.composite.resource.spec.apiKey
+  defaults: false
+sampling:
+  ratio: 0.5
+  rate: 2
+  burst: 10
+  keepErrors: false
+`,
+			want: captureSettings{
+				Format:           "text",
+				Sinks:            []string{"stderr", "file,path=/tmp/events.log,archive=true"},
+				Include:          []string{"composition=my-composition"},
+				Exclude:          []string{"function=function-auto-ready"},
+				Filter:           `eventType == "RESPONSE"`,
+				MaxPayloadBytes:  1024,
+				Redact:           []string{"hash:desired.composite.resource.spec.apiKey"},
+				SampleRatio:      0.5,
+				SampleRate:       2,
+				SampleBurst:      10,
+				SampleKeepErrors: false,
+			},
+		},
+		{
+			name:    "unknown field",
+			config:  "sink: [stdout]",
+			wantErr: true,
+		},
+		{
+			name:    "invalid format",
+			config:  "format: yaml",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := parseConfig([]byte(tt.config))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parseConfig(%q): expected error", tt.config)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseConfig(%q): %v", tt.config, err)
+			}
+			if diff := cmp.Diff(tt.want, cfg.apply(flags)); diff != "" {
+				t.Errorf("apply(...) mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestConfigReloader(t *testing.T) {
+	dir := t.TempDir()
+	cfgPath := filepath.Join(dir, "config.yaml")
+	firstPath := filepath.Join(dir, "first.log")
+	secondPath := filepath.Join(dir, "second.log")
+
+	writeConfig := func(s string) {
+		t.Helper()
+		if err := os.WriteFile(cfgPath, []byte(s), 0o600); err != nil {
+			t.Fatalf("cannot write config: %v", err)
+		}
+	}
+	writeConfig("sinks:\n- file,path=" + firstPath + "\n")
+
+	r := &configReloader{
+		path:  cfgPath,
+		flags: captureSettings{Format: "json", SampleRatio: 1, RedactionKey: "key"},
+		log:   logging.NewNopLogger(),
+	}
+	c, err := r.load()
+	if err != nil {
+		t.Fatalf("load(): %v", err)
+	}
+	r.sink = server.NewSwappableSink(c.sink)
+	r.filter = server.NewSwappableFilter(c.filter)
+	r.redacting = server.NewRedactingSink(r.sink, c.redactor)
+
+	write := func(fn string) {
+		t.Helper()
+		e := &server.Event{Type: server.EventTypeRequest, Meta: &pipelinev1alpha1.StepMeta{FunctionName: fn}}
+		if !r.filter.Match(e) {
+			return
+		}
+		if err := r.redacting.Write(e); err != nil {
+			t.Fatalf("Write(...): %v", err)
+		}
+	}
+
+	// Nothing changed, so nothing is reloaded.
+	write("function-a")
+	if err := r.reload(); err != nil {
+		t.Fatalf("reload(): %v", err)
+	}
+
+	// Invalid config is rejected, and the previous config stays in effect.
+	writeConfig("sinks: [file]\n")
+	if err := r.reload(); err == nil {
+		t.Error("reload(): expected error for invalid config")
+	}
+	write("function-b")
+
+	// Valid config swaps sinks and filters.
+	writeConfig("sinks:\n- file,path=" + secondPath + "\nexclude:\n- function=function-d\n")
+	if err := r.reload(); err != nil {
+		t.Fatalf("reload(): %v", err)
+	}
+	write("function-c")
+	write("function-d")
+
+	// Unchanged sinks are reused, rather than opened again.
+	prev := r.sinks
+	writeConfig("sinks:\n- file,path=" + secondPath + "\nexclude:\n- function=function-c\n")
+	if err := r.reload(); err != nil {
+		t.Fatalf("reload(): %v", err)
+	}
+	for key, s := range r.sinks {
+		if prev[key] != s {
+			t.Errorf("reload(): unchanged sink %s was opened again", key)
+		}
+	}
+	write("function-e")
+
+	if err := r.sink.Close(); err != nil {
+		t.Fatalf("Close(): %v", err)
+	}
+
+	read := func(path string) string {
+		t.Helper()
+		b, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("cannot read %s: %v", path, err)
+		}
+		return string(b)
+	}
+	for path, want := range map[string][]string{
+		firstPath:  {"function-a", "function-b"},
+		secondPath: {"function-c", "function-e"},
+	} {
+		got := read(path)
+		for _, fn := range want {
+			if !strings.Contains(got, fn) {
+				t.Errorf("%s: want event for %s, got:\n%s", filepath.Base(path), fn, got)
+			}
+		}
+		if n := strings.Count(got, "\n"); n != len(want) {
+			t.Errorf("%s: want %d events, got %d:\n%s", filepath.Base(path), len(want), n, got)
+		}
+	}
+}
@@ -19,15 +19,22 @@ package main
 
 import (
 	"context"
+	"crypto/rand"
+	"errors"
 	"fmt"
 	"net"
+	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/alecthomas/kong"
 	"github.com/go-logr/zapr"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
 
@@ -38,60 +45,257 @@ import (
 
 // CLI arguments.
 type CLI struct {
-	Debug           bool          `help:"Emit debug logs in addition to info logs." short:"d"`
-	SocketPath      string        `default:"/var/run/pipeline-inspector/socket"     env:"PIPELINE_INSPECTOR_SOCKET" help:"Unix socket path to listen on."`
-	Format          string        `default:"json"                                   enum:"json,text"                help:"Output format (json or text)."`
-	MaxRecvMsgSize  int           `default:"4194304"                                env:"MAX_RECV_MSG_SIZE"         help:"Maximum gRPC receive message size in bytes (default 4MB)."`
-	ShutdownTimeout time.Duration `default:"5s"                                     env:"SHUTDOWN_TIMEOUT"          help:"Graceful shutdown timeout."`
+	Serve ServeCmd `cmd:"" default:"withargs" help:"Run the inspector sidecar (default)."`
+	Tail  TailCmd  `cmd:"" help:"Print events captured by a running inspector sidecar."`
+}
+
+// ServeCmd runs the inspector sidecar.
+type ServeCmd struct {
+	Debug              bool          `help:"Emit debug logs in addition to info logs."                                                                                                   short:"d"`
+	SocketPath         string        `default:"/var/run/pipeline-inspector/socket"                                                                                                       env:"PIPELINE_INSPECTOR_SOCKET"                                                                                                                 help:"Unix socket path to listen on."`
+	Format             string        `default:"json"                                                                                                                                     enum:"json,text,summary"                                                                                                                        help:"Output format (json, text or summary)."`
+	Sinks              []string      `help:"Sink to write events to, as KIND[,KEY=VALUE...]. May be repeated. Defaults to stdout in --format."                                           name:"sink"                                                                                                                                     placeholder:"KIND[,KEY=VALUE...]"                                                          sep:"none"`
+	MaxRecvMsgSize     int           `default:"4194304"                                                                                                                                  env:"MAX_RECV_MSG_SIZE"                                                                                                                         help:"Maximum gRPC receive message size in bytes (default 4MB)."`
+	ShutdownTimeout    time.Duration `default:"5s"                                                                                                                                       env:"SHUTDOWN_TIMEOUT"                                                                                                                          help:"Graceful shutdown timeout."`
+	QueueSize          int           `default:"1000"                                                                                                                                     env:"QUEUE_SIZE"                                                                                                                                help:"Maximum number of events queued for asynchronous writing. Set to 0 to write events synchronously."`
+	QueueWorkers       int           `default:"1"                                                                                                                                        env:"QUEUE_WORKERS"                                                                                                                             help:"Number of goroutines writing queued events. Events may be written out of order when greater than 1, so it must be 1 when steps are paired."`
+	DropPolicy         string        `default:"drop-newest"                                                                                                                              enum:"drop-newest,drop-oldest,block"                                                                                                            env:"DROP_POLICY"                                                                          help:"What to do when the event queue is full (drop-newest, drop-oldest or block)."`
+	BlockTimeout       time.Duration `default:"1s"                                                                                                                                       env:"BLOCK_TIMEOUT"                                                                                                                             help:"How long to wait for room in a full queue before dropping an event when --drop-policy=block."`
+	PairSteps          bool          `env:"PAIR_STEPS"                                                                                                                                   help:"Pair each function's REQUEST and RESPONSE events into a single STEP event."`
+	StepTimeout        time.Duration `default:"1m"                                                                                                                                       env:"STEP_TIMEOUT"                                                                                                                              help:"How long a request waits for its response before it is written as an incomplete STEP event."`
+	StepDiff           string        `default:"off"                                                                                                                                      enum:"off,include,only"                                                                                                                         env:"STEP_DIFF"                                                                            help:"Add a diff of each step's desired state to STEP events, alongside (include) or instead of (only) the request and response. Implies --pair-steps."`
+	AggregatePipelines bool          `env:"AGGREGATE_PIPELINES"                                                                                                                          help:"Write a PIPELINE event summarizing each pipeline run. Implies --pair-steps."`
+	DetectDrift        bool          `env:"DETECT_DRIFT"                                                                                                                                 help:"Write a NONDETERMINISM event when a step returns different output for the same input, and a DRIFT event when an XR's final desired state changes between reconciles. Implies --aggregate-pipelines."`
+	DriftTTL           time.Duration `default:"1h"                                                                                                                                       env:"DRIFT_TTL"                                                                                                                                 help:"How long to remember an XR's last reconcile when --detect-drift is set."`
+	PipelineTimeout    time.Duration `default:"30s"                                                                                                                                      env:"PIPELINE_TIMEOUT"                                                                                                                          help:"How long a pipeline run may be idle before it is written as an incomplete PIPELINE event."`
+	APIAddress         string        `env:"API_ADDRESS"                                                                                                                                  help:"Address to serve the HTTP query API on, e.g. :8080 or unix:///path/to/socket. Disabled if empty."`
+	BufferEvents       int           `default:"1000"                                                                                                                                     env:"BUFFER_EVENTS"                                                                                                                             help:"Maximum number of recent events kept in memory for the query API."`
+	BufferBytes        int64         `default:"67108864"                                                                                                                                 env:"BUFFER_BYTES"                                                                                                                              help:"Maximum size in bytes of recent events kept in memory for the query API (default 64MB)."`
+	StreamBuffer       int           `default:"100"                                                                                                                                      env:"STREAM_BUFFER"                                                                                                                             help:"Maximum number of events buffered for each live stream client. Events are dropped for clients that fall behind."`
+	Include            []string      `help:"Only capture events matching all of these KEY=VALUE pairs, e.g. composition=my-composition. May be repeated to capture events matching any." placeholder:"KEY=VALUE[,KEY=VALUE...]"                                                                                                          sep:"none"`
+	Exclude            []string      `help:"Don't capture events matching all of these KEY=VALUE pairs, e.g. function=function-auto-ready. May be repeated."                             placeholder:"KEY=VALUE[,KEY=VALUE...]"                                                                                                          sep:"none"`
+	SampleRatio        float64       `default:"1"                                                                                                                                        env:"SAMPLE_RATIO"                                                                                                                              help:"Fraction of pipeline runs to capture, between 0 and 1. Runs are chosen by trace ID."`
+	SampleRate         float64       `env:"SAMPLE_RATE"                                                                                                                                  help:"Maximum pipeline runs captured per second for each XR or operation. Disabled if 0."`
+	SampleBurst        int           `default:"5"                                                                                                                                        env:"SAMPLE_BURST"                                                                                                                              help:"Maximum burst of pipeline runs captured for each XR or operation when --sample-rate is set."`
+	SampleKeepErrors   bool          `default:"true"                                                                                                                                     env:"SAMPLE_KEEP_ERRORS"                                                                                                                        help:"Always capture responses with an error, even if their pipeline run was sampled out." negatable:""`
+	Filter             string        `env:"FILTER"                                                                                                                                       help:"Only write events for which this CEL expression is true. Applies to all sinks."`
+	MaxPayloadBytes    int           `env:"MAX_PAYLOAD_BYTES"                                                                                                                            help:"Replace payloads larger than this many bytes of JSON with a summary, except in archive sinks. Disabled if 0."`
+	Redact             []string      `help:"Redact values at a field path, as ACTION:PATH where ACTION is mask, hash or drop. May be repeated."                                          placeholder:"ACTION:PATH"                                                                                                                       sep:"none"`
+	RedactionKey       string        `env:"REDACTION_KEY"                                                                                                                                help:"Key used to HMAC values redacted with the hash action. Defaults to a random key, so hashes are only comparable until restart."`
+	DefaultRedactions  bool          `default:"true"                                                                                                                                     env:"DEFAULT_REDACTIONS"                                                                                                                        help:"Mask function credentials, connection details and Secret data."                      negatable:""`
+	MetricsAddress     string        `env:"METRICS_ADDRESS"                                                                                                                              help:"Address to serve Prometheus metrics on at /metrics, e.g. :8082 or unix:///path/to/socket. Disabled if empty."`
+	HealthAddress      string        `env:"HEALTH_ADDRESS"                                                                                                                               help:"Address to serve the /healthz and /readyz health probes on, e.g. :8083 or unix:///path/to/socket. Disabled if empty."`
+	Config             string        `env:"CONFIG_FILE"                                                                                                                                  help:"YAML config file of sinks, filters, redaction, sampling and formats. Overrides the corresponding flags, and is reloaded when it changes." type:"path"`
+	ConfigPollInterval time.Duration `default:"10s"                                                                                                                                      env:"CONFIG_POLL_INTERVAL"                                                                                                                      help:"How often to check the config file for changes."`
 }
 
 func main() {
 	var cli CLI
-	kong.Parse(&cli)
+	ctx := kong.Parse(&cli)
 
-	if err := run(cli); err != nil {
+	if err := ctx.Run(); err != nil {
 		fmt.Fprintf(os.Stderr, "error: %v\n", err)
 		os.Exit(1)
 	}
 }
 
-func run(cli CLI) error {
+// Validate the supplied flags.
+func (c *ServeCmd) Validate() error {
+	// Events are paired behind the queue. Pairing needs each request to
+	// arrive before its response, which more than one worker doesn't ensure.
+	if c.QueueSize > 0 && c.QueueWorkers > 1 && c.pairSteps() {
+		return errors.New("--queue-workers must be 1 when --pair-steps, --step-diff, --aggregate-pipelines or --detect-drift is set, because they need events in order")
+	}
+	return nil
+}
+
+// pairSteps returns true if requests must be paired with responses, either
+// because that was asked for or because another feature needs STEP events.
+func (c *ServeCmd) pairSteps() bool {
+	return c.PairSteps || c.AggregatePipelines || c.StepDiff != server.StepDiffOff || c.DetectDrift
+}
+
+// Run the inspector sidecar.
+func (c *ServeCmd) Run() error {
 	// Create logger.
-	log, err := newLogger(cli.Debug)
+	log, err := newLogger(c.Debug)
 	if err != nil {
 		return fmt.Errorf("cannot create logger: %w", err)
 	}
 
+	// Keep recent events in memory, so they can be queried and streamed over
+	// HTTP.
+	var extra []server.Sink
+	var api *http.Server
+	if c.APIAddress != "" {
+		ring := server.NewRingBuffer(c.BufferEvents, c.BufferBytes)
+		live := server.NewBroadcaster(server.WithSubscriberBuffer(c.StreamBuffer))
+		extra = append(extra, ring, live)
+		api = &http.Server{
+			Addr:              c.APIAddress,
+			Handler:           server.NewEventsHandler(ring, server.WithBroadcaster(live)),
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+		// Streams never go idle, so end them when the API shuts down.
+		api.RegisterOnShutdown(func() { _ = live.Close() })
+	}
+
+	// Record metrics about captured events and sinks.
+	var metrics *server.Metrics
+	var metricsAPI *http.Server
+	reg := prometheus.NewRegistry()
+	if c.MetricsAddress != "" {
+		metrics = server.NewMetrics()
+		reg.MustRegister(metrics, collectors.NewGoCollector(), collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", promhttp.HandlerFor(reg, promhttp.HandlerOpts{}))
+		metricsAPI = &http.Server{
+			Addr:              c.MetricsAddress,
+			Handler:           mux,
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+	}
+
+	// Build the sinks, filters and redactor, which can be changed by
+	// reloading the config file.
+	settings := c.captureSettings()
+	if settings.RedactionKey == "" {
+		// Keep hashes comparable across reloads.
+		settings.RedactionKey = rand.Text()
+	}
+	deps := sinkDeps{metrics: metrics, log: log, stepTimeout: c.StepTimeout}
+	reloader := &configReloader{
+		path:  c.Config,
+		flags: settings,
+		deps:  deps,
+		extra: extra,
+		log:   log,
+	}
+	var current *capture
+	if c.Config != "" {
+		current, err = reloader.load()
+	} else {
+		current, err = buildCapture(settings, deps, nil, extra...)
+	}
+	if err != nil {
+		return err
+	}
+	reloader.sink = server.NewSwappableSink(current.sink)
+	reloader.filter = server.NewSwappableFilter(current.filter)
+	var sink server.Sink = reloader.sink
+
+	// Diff the desired state of each step. This needs paired STEP events.
+	if c.StepDiff != server.StepDiffOff {
+		sink = server.NewStepDiffSink(sink, c.StepDiff)
+	}
+
+	// Detect drift between reconciles. This needs STEP and PIPELINE events
+	// with full payloads, so it must happen before steps are diffed.
+	if c.DetectDrift {
+		sink = server.NewDriftSink(sink, server.WithDriftTTL(c.DriftTTL))
+	}
+
+	// Summarize pipeline runs. This needs paired STEP events.
+	if c.AggregatePipelines || c.DetectDrift {
+		sink = server.NewPipelineSink(sink,
+			server.WithPipelineTimeout(c.PipelineTimeout),
+			server.WithPipelineLogger(log),
+		)
+	}
+
+	// Pair requests with responses. This must happen before events are
+	// written to individual sinks, so every sink sees the same STEP events.
+	if c.pairSteps() {
+		sink = server.NewPairingSink(sink,
+			server.WithStepTimeout(c.StepTimeout),
+			server.WithPairingLogger(log),
+		)
+	}
+
+	// Redact sensitive values before events reach any sink.
+	reloader.redacting = server.NewRedactingSink(sink, current.redactor)
+	sink = reloader.redacting
+
+	// Write events asynchronously, so slow sinks don't add latency to the
+	// function pipeline.
+	var queue *server.QueueSink
+	if c.QueueSize > 0 {
+		queue = server.NewQueueSink(sink,
+			server.WithQueueSize(c.QueueSize),
+			server.WithQueueWorkers(c.QueueWorkers),
+			server.WithDropPolicy(c.DropPolicy),
+			server.WithBlockTimeout(c.BlockTimeout),
+			server.WithQueueLogger(log),
+		)
+		sink = queue
+		if metrics != nil {
+			reg.MustRegister(server.NewQueueCollectors(queue)...)
+		}
+	}
+
+	// Report readiness once we're serving, as long as sinks can write events.
+	health := server.NewHealth(sink)
+	var healthAPI *http.Server
+	if c.HealthAddress != "" {
+		healthAPI = &http.Server{
+			Addr:              c.HealthAddress,
+			Handler:           health,
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+	}
+
 	// Remove existing socket file if it exists.
-	if err := os.Remove(cli.SocketPath); err != nil && !os.IsNotExist(err) {
+	if err := os.Remove(c.SocketPath); err != nil && !os.IsNotExist(err) {
 		return fmt.Errorf("cannot remove existing socket: %w", err)
 	}
 
 	// Listen on Unix socket.
 	lc := net.ListenConfig{}
-	listener, err := lc.Listen(context.Background(), "unix", cli.SocketPath)
+	listener, err := lc.Listen(context.Background(), "unix", c.SocketPath)
 	if err != nil {
 		return fmt.Errorf("cannot listen on socket: %w", err)
 	}
 	defer func() { _ = listener.Close() }()
 
-	log.Info("Pipeline Inspector listening", "socket", cli.SocketPath, "format", cli.Format)
+	log.Info("Pipeline Inspector listening", "socket", c.SocketPath, "config", c.Config)
+
+	// Serve the query API, metrics and health probes.
+	if err := serveHTTP(lc, api, "query API", log); err != nil {
+		return err
+	}
+	if err := serveHTTP(lc, metricsAPI, "metrics", log); err != nil {
+		return err
+	}
+	if err := serveHTTP(lc, healthAPI, "health probes", log); err != nil {
+		return err
+	}
 
 	// Create gRPC server.
-	grpcServer := grpc.NewServer(grpc.MaxRecvMsgSize(cli.MaxRecvMsgSize))
-	inspector := server.NewInspector(cli.Format, server.WithLogger(log))
+	grpcServer := grpc.NewServer(grpc.MaxRecvMsgSize(c.MaxRecvMsgSize))
+	inspector := server.NewInspector(c.Format, server.WithSink(sink), server.WithFilter(reloader.filter), server.WithMetrics(metrics), server.WithLogger(log))
+	defer func() { _ = inspector.Close() }()
 	pipelinev1alpha1.RegisterPipelineInspectorServiceServer(grpcServer, inspector)
+	health.Register(grpcServer)
 
 	// Handle shutdown signals.
 	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer cancel()
 
+	go health.Run(ctx)
+	if c.Config != "" {
+		go reloader.Run(ctx, c.ConfigPollInterval)
+	}
+
+	shutdown := make(chan struct{})
 	go func() {
+		defer close(shutdown)
+
 		<-ctx.Done()
 		log.Info("Shutting down")
+		health.SetServing(false)
 
 		// Create a timeout context for graceful shutdown.
-		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), cli.ShutdownTimeout)
+		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), c.ShutdownTimeout)
 		defer shutdownCancel()
 
 		// Try graceful shutdown first.
@@ -108,16 +312,122 @@ func run(cli CLI) error {
 		case <-stopped:
 			// Graceful shutdown completed.
 		}
+
+		// Stop serving the query API, metrics and health probes.
+		shutdownHTTP(shutdownCtx, api, "query API", log)
+		shutdownHTTP(shutdownCtx, metricsAPI, "metrics", log)
+		shutdownHTTP(shutdownCtx, healthAPI, "health probes", log)
+
+		// Write any queued events before the shutdown timeout expires.
+		if queue != nil {
+			if err := queue.Drain(shutdownCtx); err != nil {
+				log.Info("Cannot drain event queue before shutdown timeout", "queued", queue.Len())
+			}
+			if dropped := queue.Dropped(); dropped > 0 {
+				log.Info("Dropped events because the event queue was full", "dropped", dropped)
+			}
+		}
+
+		// Commit anything written to file sinks to stable storage.
+		if err := inspector.Sync(); err != nil {
+			log.Info("Cannot sync sinks", "error", err)
+		}
 	}()
 
 	// Serve requests.
-	if err := grpcServer.Serve(listener); err != nil {
+	health.SetServing(true)
+	err = grpcServer.Serve(listener)
+
+	// Wait for the shutdown handler to drain and sync sinks. Cancelling the
+	// context starts shutdown if Serve returned due to an error.
+	cancel()
+	<-shutdown
+
+	if err != nil {
 		return fmt.Errorf("server error: %w", err)
 	}
+	return nil
+}
+
+// buildFilter builds a filter from the supplied --include and --exclude flag
+// values.
+func buildFilter(include, exclude []string) (*server.IncludeExcludeFilter, error) {
+	f := &server.IncludeExcludeFilter{}
+	for _, s := range include {
+		q, err := server.ParseMatch(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --include %q: %w", s, err)
+		}
+		f.Include = append(f.Include, q)
+	}
+	for _, s := range exclude {
+		q, err := server.ParseMatch(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --exclude %q: %w", s, err)
+		}
+		f.Exclude = append(f.Exclude, q)
+	}
+	return f, nil
+}
 
+// newRedactor returns a redactor that applies the supplied ACTION:PATH rules.
+func newRedactor(rules []string, key string, defaults bool) (*server.Redactor, error) {
+	opts := []server.RedactorOption{server.WithHMACKey([]byte(key))}
+	if !defaults {
+		opts = append(opts, server.WithoutDefaultRedactions())
+	}
+	for _, s := range rules {
+		r, err := server.ParseRedactionRule(s)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, server.WithRedactionRules(r))
+	}
+	return server.NewRedactor(opts...)
+}
+
+// serveHTTP serves the supplied HTTP server in the background, if it isn't
+// nil.
+func serveHTTP(lc net.ListenConfig, srv *http.Server, name string, log logging.Logger) error {
+	if srv == nil {
+		return nil
+	}
+	l, err := listenHTTP(lc, srv.Addr)
+	if err != nil {
+		return fmt.Errorf("cannot listen on %s address: %w", name, err)
+	}
+	go func() {
+		if err := srv.Serve(l); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Info("Cannot serve "+name, "error", err)
+		}
+	}()
+	log.Info("Serving "+name, "address", srv.Addr)
 	return nil
 }
 
+// shutdownHTTP gracefully stops the supplied HTTP server, if it isn't nil.
+func shutdownHTTP(ctx context.Context, srv *http.Server, name string, log logging.Logger) {
+	if srv == nil {
+		return
+	}
+	if err := srv.Shutdown(ctx); err != nil {
+		log.Info("Cannot gracefully stop "+name, "error", err)
+	}
+}
+
+// listenHTTP listens on the supplied HTTP address. Addresses prefixed with
+// unix:// are Unix socket paths, and any existing socket is removed.
+func listenHTTP(lc net.ListenConfig, address string) (net.Listener, error) {
+	path, ok := strings.CutPrefix(address, "unix://")
+	if !ok {
+		return lc.Listen(context.Background(), "tcp", address)
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("cannot remove existing socket: %w", err)
+	}
+	return lc.Listen(context.Background(), "unix", path)
+}
+
 // newLogger creates a new logger based on the debug flag.
 func newLogger(debug bool) (logging.Logger, error) {
 	var zl *zap.Logger
@@ -0,0 +1,71 @@
+/*
+Copyright 2026 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+this file except in compliance with the License. You may obtain a copy of the
+License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed
+under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+CONDITIONS OF ANY KIND, either express or implied. See the License for the
+specific language governing permissions and limitations under the License.
+*/
+
+package main
+
+import (
+	"testing"
+
+	"github.com/alecthomas/kong"
+)
+
+func TestServeCmdValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr bool
+	}{
+		{
+			name: "defaults",
+			args: []string{"serve"},
+		},
+		{
+			name: "many workers without pairing",
+			args: []string{"serve", "--queue-workers=4"},
+		},
+		{
+			name: "one worker with pairing",
+			args: []string{"serve", "--pair-steps"},
+		},
+		{
+			name: "many workers writing synchronously",
+			args: []string{"serve", "--queue-workers=4", "--queue-size=0", "--detect-drift"},
+		},
+		{
+			name:    "many workers with pairing",
+			args:    []string{"serve", "--queue-workers=4", "--pair-steps"},
+			wantErr: true,
+		},
+		{
+			name:    "many workers with step diffs",
+			args:    []string{"serve", "--queue-workers=2", "--step-diff=only"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cli CLI
+			p, err := kong.New(&cli, kong.Exit(func(int) {}))
+			if err != nil {
+				t.Fatalf("kong.New(...): %v", err)
+			}
+			_, err = p.Parse(tt.args)
+			if gotErr := err != nil; gotErr != tt.wantErr {
+				t.Errorf("Parse(%v): want error %t, got %v", tt.args, tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,191 @@
+/*
+Copyright 2026 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+this file except in compliance with the License. You may obtain a copy of the
+License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed
+under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+CONDITIONS OF ANY KIND, either express or implied. See the License for the
+specific language governing permissions and limitations under the License.
+*/
+
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// API paths served by the events handler.
+const (
+	// PathEvents lists events matching the query parameters understood by
+	// ParseQuery.
+	PathEvents = "/api/v1/events"
+
+	// PathSpanEvents fetches the events of a single function call.
+	PathSpanEvents = "/api/v1/events/{spanId}"
+
+	// PathStream streams live events matching the query parameters understood
+	// by ParseQuery as server-sent events.
+	PathStream = "/api/v1/events/stream"
+)
+
+// A HandlerOption configures the events handler.
+type HandlerOption func(*eventsHandler)
+
+// WithBroadcaster serves live events from the supplied broadcaster at
+// PathStream.
+func WithBroadcaster(b *Broadcaster) HandlerOption {
+	return func(h *eventsHandler) {
+		h.broadcaster = b
+	}
+}
+
+// WithKeepAlive sets how often an idle stream sends a comment to keep the
+// connection open (default: 15s).
+func WithKeepAlive(d time.Duration) HandlerOption {
+	return func(h *eventsHandler) {
+		h.keepAlive = d
+	}
+}
+
+type eventsHandler struct {
+	ring        *RingBuffer
+	broadcaster *Broadcaster
+	keepAlive   time.Duration
+}
+
+// NewEventsHandler returns an HTTP handler that serves events kept by the
+// supplied ring buffer. Events are returned as a JSON array, oldest first.
+// Each event has the same JSON shape as the lines written by a JSONSink.
+func NewEventsHandler(r *RingBuffer, opts ...HandlerOption) http.Handler {
+	h := &eventsHandler{ring: r, keepAlive: 15 * time.Second}
+	for _, opt := range opts {
+		opt(h)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET "+PathEvents, h.list)
+	mux.HandleFunc("GET "+PathSpanEvents, h.span)
+	if h.broadcaster != nil {
+		mux.HandleFunc("GET "+PathStream, h.stream)
+	}
+	return mux
+}
+
+func (h *eventsHandler) list(w http.ResponseWriter, req *http.Request) {
+	q, err := ParseQuery(req.URL.Query(), time.Now())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	writeEvents(w, h.ring.List(q))
+}
+
+func (h *eventsHandler) span(w http.ResponseWriter, req *http.Request) {
+	events := h.ring.List(Query{SpanID: req.PathValue("spanId")})
+	if len(events) == 0 {
+		http.Error(w, "no events found for span", http.StatusNotFound)
+		return
+	}
+	writeEvents(w, events)
+}
+
+// stream writes each matching event as a server-sent event whose data is the
+// event's JSON encoding. If the query has a since time or a limit, matching
+// events from the ring buffer are sent before live events.
+func (h *eventsHandler) stream(w http.ResponseWriter, req *http.Request) {
+	q, err := ParseQuery(req.URL.Query(), time.Now())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+
+	// Subscribe before reading the ring buffer so no events are missed. An
+	// event written after we subscribed may also be in the ring buffer, so
+	// remember which events we've already sent.
+	sub := h.broadcaster.Subscribe(q)
+	defer sub.Close()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+
+	sent := make(map[*Event]bool)
+	if h.ring != nil && (!q.Since.IsZero() || q.Limit > 0) {
+		for _, e := range h.ring.List(q) {
+			if err := writeServerSentEvent(w, e); err != nil {
+				return
+			}
+			sent[e] = true
+		}
+	}
+	flusher.Flush()
+
+	t := time.NewTicker(h.keepAlive)
+	defer t.Stop()
+
+	var dropped uint64
+	for {
+		select {
+		case <-req.Context().Done():
+			return
+		case <-t.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+		case e, ok := <-sub.Events():
+			if !ok {
+				return
+			}
+			if sent[e] {
+				delete(sent, e)
+				continue
+			}
+			// Tell the client if it's too slow to keep up.
+			if d := sub.Dropped(); d > dropped {
+				if _, err := fmt.Fprintf(w, ": dropped %d events\n\n", d-dropped); err != nil {
+					return
+				}
+				dropped = d
+			}
+			if err := writeServerSentEvent(w, e); err != nil {
+				return
+			}
+		}
+		flusher.Flush()
+	}
+}
+
+func writeServerSentEvent(w http.ResponseWriter, e *Event) error {
+	j, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "data: %s\n\n", j)
+	return err
+}
+
+func writeEvents(w http.ResponseWriter, events []*Event) {
+	if events == nil {
+		events = []*Event{}
+	}
+	body, err := json.Marshal(events)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(body)
+}
@@ -0,0 +1,182 @@
+/*
+Copyright 2026 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+this file except in compliance with the License. You may obtain a copy of the
+License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed
+under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+CONDITIONS OF ANY KIND, either express or implied. See the License for the
+specific language governing permissions and limitations under the License.
+*/
+
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pipelinev1alpha1 "github.com/crossplane/crossplane-runtime/v2/apis/pipelineinspector/proto/v1alpha1"
+)
+
+func TestEventsHandler(t *testing.T) {
+	r := NewRingBuffer(10, 0)
+	req := &Event{
+		Type:    EventTypeRequest,
+		Meta:    &pipelinev1alpha1.StepMeta{SpanId: "span-1", FunctionName: "function-a"},
+		Payload: map[string]any{"key": "value"},
+	}
+	rsp := &Event{
+		Type:  EventTypeResponse,
+		Meta:  &pipelinev1alpha1.StepMeta{SpanId: "span-1", FunctionName: "function-a"},
+		Error: "boom",
+	}
+	other := &Event{Type: EventTypeRequest, Meta: &pipelinev1alpha1.StepMeta{SpanId: "span-2", FunctionName: "function-b"}}
+	for _, e := range []*Event{req, rsp, other} {
+		_ = r.Write(e)
+	}
+
+	// Each event must have the same shape as a JSONSink line.
+	line := func(e *Event) json.RawMessage {
+		var buf bytes.Buffer
+		_ = NewJSONSink(&buf).Write(e)
+		return json.RawMessage(strings.TrimSpace(buf.String()))
+	}
+
+	tests := []struct {
+		name       string
+		path       string
+		wantStatus int
+		want       []json.RawMessage
+	}{
+		{
+			name:       "list all",
+			path:       "/api/v1/events",
+			wantStatus: http.StatusOK,
+			want:       []json.RawMessage{line(req), line(rsp), line(other)},
+		},
+		{
+			name:       "list filtered",
+			path:       "/api/v1/events?function=function-b",
+			wantStatus: http.StatusOK,
+			want:       []json.RawMessage{line(other)},
+		},
+		{
+			name:       "list none",
+			path:       "/api/v1/events?traceId=nope",
+			wantStatus: http.StatusOK,
+			want:       []json.RawMessage{},
+		},
+		{
+			name:       "list invalid query",
+			path:       "/api/v1/events?limit=lots",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "fetch span",
+			path:       "/api/v1/events/span-1",
+			wantStatus: http.StatusOK,
+			want:       []json.RawMessage{line(req), line(rsp)},
+		},
+		{
+			name:       "fetch unknown span",
+			path:       "/api/v1/events/span-3",
+			wantStatus: http.StatusNotFound,
+		},
+	}
+
+	h := NewEventsHandler(r)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status: want %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var got []json.RawMessage
+			if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+				t.Fatalf("cannot unmarshal body: %v", err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("events mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestEventsHandler_Stream(t *testing.T) {
+	r := NewRingBuffer(10, 0)
+	b := NewBroadcaster()
+	sinks := NewFanOutSink(r, b)
+
+	event := func(span, fn string) *Event {
+		return &Event{
+			Type: EventTypeRequest,
+			Meta: &pipelinev1alpha1.StepMeta{SpanId: span, FunctionName: fn, Timestamp: timestamppb.Now()},
+		}
+	}
+	_ = sinks.Write(event("old", "function-a"))
+
+	srv := httptest.NewServer(NewEventsHandler(r, WithBroadcaster(b)))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/api/v1/events/stream?function=function-a&since=1h", nil)
+	rsp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("GET stream failed: %v", err)
+	}
+	defer rsp.Body.Close()
+
+	if got := rsp.Header.Get("Content-Type"); got != "text/event-stream" {
+		t.Errorf("Content-Type: want text/event-stream, got %q", got)
+	}
+
+	// Wait for the handler to subscribe before writing live events.
+	for b.Subscribers() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	_ = sinks.Write(event("filtered", "function-b"))
+	_ = sinks.Write(event("new", "function-a"))
+
+	var got []string
+	sc := bufio.NewScanner(rsp.Body)
+	for len(got) < 2 && sc.Scan() {
+		data, ok := strings.CutPrefix(sc.Text(), "data: ")
+		if !ok {
+			continue
+		}
+		e := struct {
+			Meta struct {
+				SpanID string `json:"spanId"`
+			} `json:"meta"`
+		}{}
+		if err := json.Unmarshal([]byte(data), &e); err != nil {
+			t.Fatalf("cannot unmarshal event %q: %v", data, err)
+		}
+		got = append(got, e.Meta.SpanID)
+	}
+
+	if diff := cmp.Diff([]string{"old", "new"}, got); diff != "" {
+		t.Errorf("streamed events mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,133 @@
+/*
+Copyright 2026 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+this file except in compliance with the License. You may obtain a copy of the
+License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed
+under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+CONDITIONS OF ANY KIND, either express or implied. See the License for the
+specific language governing permissions and limitations under the License.
+*/
+
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/cel-go/cel"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// Variables available to CEL filter expressions.
+const (
+	celEventType = "eventType"
+	celMeta      = "meta"
+	celPayload   = "payload"
+	celError     = "error"
+)
+
+// A CELFilter matches events using a CEL expression. Expressions can use these
+// variables, which have the same shape as the event's JSON encoding:
+//
+//   - eventType: the event's type, for example RESPONSE
+//   - meta: the event's metadata, for example meta.functionName
+//   - payload: the decoded payload, for example payload.results
+//   - error: the error returned by the function, or an empty string
+//
+// An expression that fails to evaluate, for example because it uses a field
+// the payload doesn't have, doesn't match.
+type CELFilter struct {
+	prg cel.Program
+}
+
+// NewCELFilter compiles the supplied CEL expression, which must evaluate to a
+// bool. It returns an error if the expression is invalid.
+func NewCELFilter(expr string) (*CELFilter, error) {
+	env, err := cel.NewEnv(
+		cel.Variable(celEventType, cel.StringType),
+		cel.Variable(celMeta, cel.MapType(cel.StringType, cel.DynType)),
+		cel.Variable(celPayload, cel.DynType),
+		cel.Variable(celError, cel.StringType),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create CEL environment: %w", err)
+	}
+
+	ast, iss := env.Compile(expr)
+	if iss.Err() != nil {
+		return nil, fmt.Errorf("invalid CEL expression %q: %w", expr, iss.Err())
+	}
+	if t := ast.OutputType(); !t.IsExactType(cel.BoolType) && !t.IsExactType(cel.DynType) {
+		return nil, fmt.Errorf("invalid CEL expression %q: must evaluate to bool, not %s", expr, t)
+	}
+	prg, err := env.Program(ast)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CEL expression %q: %w", expr, err)
+	}
+	return &CELFilter{prg: prg}, nil
+}
+
+// Match returns true if the expression evaluates to true for the supplied
+// event.
+func (f *CELFilter) Match(e *Event) bool {
+	vars, err := celVariables(e)
+	if err != nil {
+		return false
+	}
+	out, _, err := f.prg.Eval(vars)
+	if err != nil {
+		return false
+	}
+	b, ok := out.Value().(bool)
+	return ok && b
+}
+
+// celVariables returns the CEL variables for the supplied event, converting
+// its metadata and payload to generic JSON values.
+func celVariables(e *Event) (map[string]any, error) {
+	meta := map[string]any{}
+	if e.Meta != nil {
+		j, err := protojson.Marshal(e.Meta)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(j, &meta); err != nil {
+			return nil, err
+		}
+	}
+
+	payload, err := jsonValue(e.Payload)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]any{
+		celEventType: e.Type,
+		celMeta:      meta,
+		celPayload:   payload,
+		celError:     e.Error,
+	}, nil
+}
+
+// jsonValue returns the supplied payload as generic JSON values. Payloads such
+// as steps are converted to their JSON shape.
+func jsonValue(payload any) (any, error) {
+	switch payload.(type) {
+	case nil, map[string]any, []any, string:
+		return payload, nil
+	}
+	j, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	var v any
+	if err := json.Unmarshal(j, &v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
@@ -0,0 +1,114 @@
+/*
+Copyright 2026 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+this file except in compliance with the License. You may obtain a copy of the
+License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed
+under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+CONDITIONS OF ANY KIND, either express or implied. See the License for the
+specific language governing permissions and limitations under the License.
+*/
+
+package server
+
+import (
+	"testing"
+
+	pipelinev1alpha1 "github.com/crossplane/crossplane-runtime/v2/apis/pipelineinspector/proto/v1alpha1"
+)
+
+func TestCELFilter(t *testing.T) {
+	fatal := &Event{
+		Type: EventTypeResponse,
+		Meta: &pipelinev1alpha1.StepMeta{FunctionName: "function-a"},
+		Payload: map[string]any{
+			"results": []any{
+				map[string]any{"severity": "SEVERITY_NORMAL"},
+				map[string]any{"severity": "SEVERITY_FATAL", "message": "boom"},
+			},
+		},
+	}
+	notReady := &Event{
+		Type: EventTypeRequest,
+		Meta: &pipelinev1alpha1.StepMeta{
+			FunctionName: "function-b",
+			Context: &pipelinev1alpha1.StepMeta_CompositionMeta{
+				CompositionMeta: &pipelinev1alpha1.CompositionMeta{CompositeResourceKind: "XDatabase"},
+			},
+		},
+		Payload: map[string]any{
+			"observed": map[string]any{"composite": map[string]any{"resource": map[string]any{
+				"status": map[string]any{"conditions": []any{map[string]any{"type": "Ready", "status": "False"}}},
+			}}},
+		},
+	}
+	failed := &Event{
+		Type:    EventTypeStep,
+		Meta:    &pipelinev1alpha1.StepMeta{FunctionName: "function-c"},
+		Payload: &Step{Request: map[string]any{}, Response: map[string]any{"results": []any{}}},
+		Error:   "boom",
+	}
+
+	tests := []struct {
+		name string
+		expr string
+		want map[string]bool
+	}{
+		{
+			name: "fatal results",
+			expr: `eventType == "RESPONSE" && payload.results.exists(r, r.severity == "SEVERITY_FATAL")`,
+			want: map[string]bool{"fatal": true},
+		},
+		{
+			name: "observed composite not ready",
+			expr: `payload.observed.composite.resource.status.conditions.exists(c, c.type == "Ready" && c.status == "False")`,
+			want: map[string]bool{"notReady": true},
+		},
+		{
+			name: "meta",
+			expr: `meta.functionName == "function-b" && meta.compositionMeta.compositeResourceKind == "XDatabase"`,
+			want: map[string]bool{"notReady": true},
+		},
+		{
+			name: "error",
+			expr: `error != ""`,
+			want: map[string]bool{"failed": true},
+		},
+		{
+			name: "step payload",
+			expr: `has(payload.response) && size(payload.response.results) == 0`,
+			want: map[string]bool{"failed": true},
+		},
+	}
+
+	events := map[string]*Event{"fatal": fatal, "notReady": notReady, "failed": failed}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := NewCELFilter(tt.expr)
+			if err != nil {
+				t.Fatalf("NewCELFilter(%q) failed: %v", tt.expr, err)
+			}
+			for name, e := range events {
+				if got := f.Match(e); got != tt.want[name] {
+					t.Errorf("Match(%s): want %t, got %t", name, tt.want[name], got)
+				}
+			}
+		})
+	}
+}
+
+func TestNewCELFilter_Invalid(t *testing.T) {
+	for _, expr := range []string{
+		`payload.results.exists(r,`,
+		`eventType + "!"`,
+		`unknown == 1`,
+	} {
+		if _, err := NewCELFilter(expr); err == nil {
+			t.Errorf("NewCELFilter(%q): expected error", expr)
+		}
+	}
+}
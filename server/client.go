@@ -0,0 +1,121 @@
+/*
+Copyright 2026 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+this file except in compliance with the License. You may obtain a copy of the
+License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed
+under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+CONDITIONS OF ANY KIND, either express or implied. See the License for the
+specific language governing permissions and limitations under the License.
+*/
+
+package server
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// A Client reads events from the HTTP API served by NewEventsHandler.
+type Client struct {
+	base string
+	http *http.Client
+}
+
+// NewClient returns a client for the API at the supplied address. The address
+// is either an HTTP URL such as http://localhost:8080, or a Unix socket such as
+// unix:///var/run/pipeline-inspector/api.sock.
+func NewClient(address string) *Client {
+	path, ok := strings.CutPrefix(address, "unix://")
+	if !ok {
+		return &Client{base: strings.TrimSuffix(address, "/"), http: &http.Client{}}
+	}
+
+	d := &net.Dialer{}
+	t := &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return d.DialContext(ctx, "unix", path)
+		},
+	}
+	return &Client{base: "http://inspector", http: &http.Client{Transport: t}}
+}
+
+// List returns the buffered events matching the supplied query, oldest first.
+func (c *Client) List(ctx context.Context, q Query) ([]*Event, error) {
+	rsp, err := c.get(ctx, PathEvents, q)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rsp.Body.Close() }()
+
+	var events []*Event
+	if err := json.NewDecoder(rsp.Body).Decode(&events); err != nil {
+		return nil, fmt.Errorf("cannot decode events: %w", err)
+	}
+	return events, nil
+}
+
+// Stream calls the supplied function with each live event matching the
+// supplied query, until the context is cancelled, the server ends the stream,
+// or the function returns an error. If the query has a since time or a limit,
+// matching buffered events are streamed first.
+func (c *Client) Stream(ctx context.Context, q Query, fn func(e *Event) error) error {
+	rsp, err := c.get(ctx, PathStream, q)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = rsp.Body.Close() }()
+
+	// Each server-sent event is a single data line followed by a blank line.
+	// Lines starting with a colon are comments.
+	sc := bufio.NewScanner(rsp.Body)
+	sc.Buffer(nil, 64<<20)
+	for sc.Scan() {
+		data, ok := strings.CutPrefix(sc.Text(), "data: ")
+		if !ok {
+			continue
+		}
+		e := &Event{}
+		if err := json.Unmarshal([]byte(data), e); err != nil {
+			return fmt.Errorf("cannot decode event: %w", err)
+		}
+		if err := fn(e); err != nil {
+			return err
+		}
+	}
+	if err := sc.Err(); err != nil && ctx.Err() == nil {
+		return fmt.Errorf("cannot read stream: %w", err)
+	}
+	return nil
+}
+
+func (c *Client) get(ctx context.Context, path string, q Query) (*http.Response, error) {
+	u := c.base + path
+	if v := q.Values(); len(v) > 0 {
+		u += "?" + v.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create request: %w", err)
+	}
+	rsp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get %s: %w", path, err)
+	}
+	if rsp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(rsp.Body, 1024))
+		_ = rsp.Body.Close()
+		return nil, fmt.Errorf("cannot get %s: %s: %s", path, rsp.Status, strings.TrimSpace(string(body)))
+	}
+	return rsp, nil
+}
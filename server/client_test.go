@@ -0,0 +1,110 @@
+/*
+Copyright 2026 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+this file except in compliance with the License. You may obtain a copy of the
+License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed
+under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+CONDITIONS OF ANY KIND, either express or implied. See the License for the
+specific language governing permissions and limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/protobuf/testing/protocmp"
+
+	pipelinev1alpha1 "github.com/crossplane/crossplane-runtime/v2/apis/pipelineinspector/proto/v1alpha1"
+)
+
+var errStop = errors.New("stop")
+
+func TestClient(t *testing.T) {
+	r := NewRingBuffer(10, 0)
+	b := NewBroadcaster()
+	sinks := NewFanOutSink(r, b)
+
+	old := &Event{
+		Type:    EventTypeRequest,
+		Meta:    &pipelinev1alpha1.StepMeta{SpanId: "old", FunctionName: "function-a"},
+		Payload: map[string]any{"key": "value"},
+	}
+	live := &Event{
+		Type:  EventTypeResponse,
+		Meta:  &pipelinev1alpha1.StepMeta{SpanId: "live", FunctionName: "function-a"},
+		Error: "boom",
+	}
+	_ = sinks.Write(old)
+
+	// Serve the API on a Unix socket, like the sidecar does when it shares a
+	// volume with the client.
+	path := filepath.Join(t.TempDir(), "api.sock")
+	l, err := (&net.ListenConfig{}).Listen(context.Background(), "unix", path)
+	if err != nil {
+		t.Fatalf("cannot listen: %v", err)
+	}
+	srv := httptest.NewUnstartedServer(NewEventsHandler(r, WithBroadcaster(b)))
+	srv.Listener = l
+	srv.Start()
+	defer srv.Close()
+
+	c := NewClient("unix://" + path)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	t.Run("List", func(t *testing.T) {
+		got, err := c.List(ctx, Query{FunctionName: "function-a"})
+		if err != nil {
+			t.Fatalf("List() failed: %v", err)
+		}
+		if diff := cmp.Diff([]*Event{old}, got, protocmp.Transform()); diff != "" {
+			t.Errorf("List() mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("Stream", func(t *testing.T) {
+		go func() {
+			for b.Subscribers() == 0 {
+				time.Sleep(time.Millisecond)
+			}
+			_ = sinks.Write(live)
+		}()
+
+		var got []*Event
+		err := c.Stream(ctx, Query{Limit: 1}, func(e *Event) error {
+			got = append(got, e)
+			if len(got) == 2 {
+				return errStop
+			}
+			return nil
+		})
+		if !errors.Is(err, errStop) {
+			t.Fatalf("Stream(): want errStop, got %v", err)
+		}
+		if diff := cmp.Diff([]*Event{old, live}, got, protocmp.Transform()); diff != "" {
+			t.Errorf("Stream() mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		srv := httptest.NewServer(http.NotFoundHandler())
+		defer srv.Close()
+		if _, err := NewClient(srv.URL).List(ctx, Query{}); err == nil {
+			t.Error("List(): expected error for 404 response")
+		}
+	})
+}
@@ -0,0 +1,213 @@
+/*
+Copyright 2026 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+this file except in compliance with the License. You may obtain a copy of the
+License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed
+under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+CONDITIONS OF ANY KIND, either express or implied. See the License for the
+specific language governing permissions and limitations under the License.
+*/
+
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+	"sigs.k8s.io/yaml"
+)
+
+// Step diff modes.
+const (
+	// StepDiffOff doesn't diff steps.
+	StepDiffOff = "off"
+
+	// StepDiffInclude adds a diff to each STEP event, alongside the request
+	// and response.
+	StepDiffInclude = "include"
+
+	// StepDiffOnly replaces the request and response of each STEP event with
+	// a diff.
+	StepDiffOnly = "only"
+)
+
+// JSON Patch operations.
+const (
+	PatchOpAdd     = "add"
+	PatchOpRemove  = "remove"
+	PatchOpReplace = "replace"
+)
+
+// A PatchOperation is a JSON Patch (RFC 6902) operation.
+type PatchOperation struct {
+	Op    string
+	Path  string
+	Value any
+}
+
+// MarshalJSON marshals the operation, omitting the value of remove operations.
+func (o PatchOperation) MarshalJSON() ([]byte, error) {
+	op := map[string]any{"op": o.Op, "path": o.Path}
+	if o.Op != PatchOpRemove {
+		op["value"] = o.Value
+	}
+	return json.Marshal(op)
+}
+
+// A StepDiff describes how a function changed the desired state: the
+// differences between desired.composite and desired.resources in its
+// RunFunctionRequest and its RunFunctionResponse.
+type StepDiff struct {
+	// Patch is a JSON Patch that transforms the request's desired state into
+	// the response's. Paths are relative to the request, e.g.
+	// /desired/resources/bucket/resource/spec/region.
+	Patch []PatchOperation
+
+	from, to map[string]any
+}
+
+// NewStepDiff returns the diff between the desired state of the supplied
+// decoded RunFunctionRequest and RunFunctionResponse.
+func NewStepDiff(req, rsp any) *StepDiff {
+	return NewDesiredDiff(field(req, "desired"), field(rsp, "desired"))
+}
+
+// NewDesiredDiff returns the diff between the supplied decoded desired states,
+// i.e. the desired fields of two RunFunctionRequests or RunFunctionResponses.
+func NewDesiredDiff(from, to any) *StepDiff {
+	d := &StepDiff{from: desiredState(from), to: desiredState(to), Patch: []PatchOperation{}}
+	d.Patch = diffValues(d.Patch, "/desired", d.from, d.to)
+	return d
+}
+
+// Unified returns the diff as a unified diff of the request's and response's
+// desired state, rendered as YAML. It returns an empty string if the desired
+// state didn't change.
+func (d *StepDiff) Unified() string {
+	if len(d.Patch) == 0 {
+		return ""
+	}
+	from, err := yaml.Marshal(map[string]any{"desired": d.from})
+	if err != nil {
+		return fmt.Sprintf("cannot render request: %v\n", err)
+	}
+	to, err := yaml.Marshal(map[string]any{"desired": d.to})
+	if err != nil {
+		return fmt.Sprintf("cannot render response: %v\n", err)
+	}
+	u, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        splitLines(string(from)),
+		B:        splitLines(string(to)),
+		FromFile: "request",
+		ToFile:   "response",
+		Context:  3,
+	})
+	if err != nil {
+		return fmt.Sprintf("cannot diff: %v\n", err)
+	}
+	return u
+}
+
+// splitLines splits the supplied newline-terminated text into lines, keeping
+// each line's newline.
+func splitLines(s string) []string {
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// desiredState returns the composite and resources of the supplied decoded
+// desired state.
+func desiredState(desired any) map[string]any {
+	state := map[string]any{}
+	for _, name := range []string{"composite", "resources"} {
+		if v := field(desired, name); v != nil {
+			state[name] = v
+		}
+	}
+	return state
+}
+
+// diffValues appends the operations that transform from into to, at the
+// supplied JSON pointer, to ops. Objects are compared key by key. Arrays and
+// values of different types are replaced wholesale.
+func diffValues(ops []PatchOperation, path string, from, to any) []PatchOperation {
+	fm, fok := from.(map[string]any)
+	tm, tok := to.(map[string]any)
+	if !fok || !tok {
+		if !reflect.DeepEqual(from, to) {
+			ops = append(ops, PatchOperation{Op: PatchOpReplace, Path: path, Value: to})
+		}
+		return ops
+	}
+
+	keys := slices.Collect(maps.Keys(fm))
+	for k := range tm {
+		if _, ok := fm[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	slices.Sort(keys)
+
+	for _, k := range keys {
+		p := path + "/" + escapePointer(k)
+		fv, inFrom := fm[k]
+		tv, inTo := tm[k]
+		switch {
+		case !inTo:
+			ops = append(ops, PatchOperation{Op: PatchOpRemove, Path: p})
+		case !inFrom:
+			ops = append(ops, PatchOperation{Op: PatchOpAdd, Path: p, Value: tv})
+		default:
+			ops = diffValues(ops, p, fv, tv)
+		}
+	}
+	return ops
+}
+
+// escapePointer escapes the supplied key for use in a JSON pointer.
+func escapePointer(k string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(k)
+}
+
+// A StepDiffSink adds a StepDiff to each complete STEP event before writing
+// it to another sink. Other events are written unchanged. The events it
+// receives are never modified, so it's safe to use with other sinks.
+type StepDiffSink struct {
+	wrappedSink
+
+	only bool
+}
+
+// NewStepDiffSink returns a sink that adds diffs to STEP events. The mode is
+// StepDiffInclude or StepDiffOnly.
+func NewStepDiffSink(s Sink, mode string) *StepDiffSink {
+	return &StepDiffSink{wrappedSink: wrappedSink{sink: s}, only: mode == StepDiffOnly}
+}
+
+// Write the supplied event, adding a diff if it's a complete STEP event.
+// Incomplete steps are written unchanged, because there's nothing to diff.
+func (s *StepDiffSink) Write(e *Event) error {
+	step, ok := e.Payload.(*Step)
+	if !ok || step.Incomplete || step.Request == nil || step.Response == nil {
+		return s.sink.Write(e)
+	}
+	diffed := *step
+	diffed.Diff = NewStepDiff(step.Request, step.Response)
+	if s.only {
+		diffed.Request, diffed.Response = nil, nil
+	}
+	return s.sink.Write(withPayload(e, &diffed))
+}
@@ -0,0 +1,211 @@
+/*
+Copyright 2026 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+this file except in compliance with the License. You may obtain a copy of the
+License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed
+under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+CONDITIONS OF ANY KIND, either express or implied. See the License for the
+specific language governing permissions and limitations under the License.
+*/
+
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func desired(composite map[string]any, resources map[string]any) map[string]any {
+	d := map[string]any{}
+	if composite != nil {
+		d["composite"] = composite
+	}
+	if resources != nil {
+		d["resources"] = resources
+	}
+	return map[string]any{"desired": d}
+}
+
+func TestNewStepDiff(t *testing.T) {
+	bucket := func(region string) map[string]any {
+		return map[string]any{"resource": map[string]any{"kind": "Bucket", "spec": map[string]any{"region": region}}}
+	}
+
+	tests := []struct {
+		name string
+		req  any
+		rsp  any
+		want []PatchOperation
+	}{
+		{
+			name: "unchanged",
+			req:  desired(nil, map[string]any{"bucket": bucket("us-east-1")}),
+			rsp:  desired(nil, map[string]any{"bucket": bucket("us-east-1")}),
+			want: []PatchOperation{},
+		},
+		{
+			name: "resources added to empty desired state",
+			req:  map[string]any{},
+			rsp:  desired(nil, map[string]any{"bucket": bucket("us-east-1")}),
+			want: []PatchOperation{
+				{Op: PatchOpAdd, Path: "/desired/resources", Value: map[string]any{"bucket": bucket("us-east-1")}},
+			},
+		},
+		{
+			name: "resource added, removed and changed",
+			req:  desired(nil, map[string]any{"bucket": bucket("us-east-1"), "old": bucket("eu-west-1")}),
+			rsp:  desired(nil, map[string]any{"bucket": bucket("us-west-2"), "new": bucket("eu-west-1")}),
+			want: []PatchOperation{
+				{Op: PatchOpReplace, Path: "/desired/resources/bucket/resource/spec/region", Value: "us-west-2"},
+				{Op: PatchOpAdd, Path: "/desired/resources/new", Value: bucket("eu-west-1")},
+				{Op: PatchOpRemove, Path: "/desired/resources/old"},
+			},
+		},
+		{
+			name: "composite status and escaped keys",
+			req: desired(map[string]any{"resource": map[string]any{
+				"metadata": map[string]any{"annotations": map[string]any{"example.org/a~b": "1"}},
+			}}, nil),
+			rsp: desired(map[string]any{"resource": map[string]any{
+				"metadata": map[string]any{"annotations": map[string]any{"example.org/a~b": "2"}},
+				"status":   map[string]any{"ready": []any{"a", "b"}},
+			}}, nil),
+			want: []PatchOperation{
+				{Op: PatchOpReplace, Path: "/desired/composite/resource/metadata/annotations/example.org~1a~0b", Value: "2"},
+				{Op: PatchOpAdd, Path: "/desired/composite/resource/status", Value: map[string]any{"ready": []any{"a", "b"}}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NewStepDiff(tt.req, tt.rsp)
+			if diff := cmp.Diff(tt.want, got.Patch); diff != "" {
+				t.Errorf("NewStepDiff(...).Patch mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestStepDiffUnified(t *testing.T) {
+	req := desired(nil, map[string]any{"bucket": map[string]any{"resource": map[string]any{"region": "us-east-1"}}})
+	rsp := desired(nil, map[string]any{"bucket": map[string]any{"resource": map[string]any{"region": "us-west-2"}}})
+
+	want := `--- request
++++ response
+@@ -2,4 +2,4 @@
+   resources:
+     bucket:
+       resource:
+-        region: us-east-1
++        region: us-west-2
+`
+	if diff := cmp.Diff(want, NewStepDiff(req, rsp).Unified()); diff != "" {
+		t.Errorf("Unified() mismatch (-want +got):\n%s", diff)
+	}
+	if got := NewStepDiff(req, req).Unified(); got != "" {
+		t.Errorf("Unified() of unchanged step: want empty, got %q", got)
+	}
+}
+
+func TestPatchOperationMarshalJSON(t *testing.T) {
+	ops := []PatchOperation{
+		{Op: PatchOpAdd, Path: "/a", Value: nil},
+		{Op: PatchOpRemove, Path: "/b"},
+	}
+	got, err := json.Marshal(ops)
+	if err != nil {
+		t.Fatalf("json.Marshal(...): %v", err)
+	}
+	want := `[{"op":"add","path":"/a","value":null},{"op":"remove","path":"/b"}]`
+	if diff := cmp.Diff(want, string(got)); diff != "" {
+		t.Errorf("json.Marshal(...) mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestStepDiffSink(t *testing.T) {
+	req := desired(nil, nil)
+	rsp := desired(nil, map[string]any{"bucket": map[string]any{}})
+
+	tests := []struct {
+		name     string
+		mode     string
+		step     *Step
+		wantDiff bool
+		wantReq  bool
+	}{
+		{
+			name:     "include keeps payloads",
+			mode:     StepDiffInclude,
+			step:     &Step{Request: req, Response: rsp},
+			wantDiff: true,
+			wantReq:  true,
+		},
+		{
+			name:     "only drops payloads",
+			mode:     StepDiffOnly,
+			step:     &Step{Request: req, Response: rsp},
+			wantDiff: true,
+		},
+		{
+			name:    "incomplete steps are unchanged",
+			mode:    StepDiffOnly,
+			step:    &Step{Request: req, Incomplete: true},
+			wantReq: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got *Event
+			s := NewStepDiffSink(SinkFunc(func(e *Event) error {
+				got = e
+				return nil
+			}), tt.mode)
+
+			in := &Event{Type: EventTypeStep, Payload: tt.step}
+			if err := s.Write(in); err != nil {
+				t.Fatalf("Write(...): %v", err)
+			}
+			if tt.step.Diff != nil {
+				t.Error("Write(...) modified the supplied step")
+			}
+
+			step := got.Payload.(*Step)
+			if gotDiff := step.Diff != nil; gotDiff != tt.wantDiff {
+				t.Errorf("Diff set: want %t, got %t", tt.wantDiff, gotDiff)
+			}
+			if gotReq := step.Request != nil; gotReq != tt.wantReq {
+				t.Errorf("Request set: want %t, got %t", tt.wantReq, gotReq)
+			}
+		})
+	}
+}
+
+func TestTextSinkStepDiff(t *testing.T) {
+	req := desired(nil, nil)
+	rsp := desired(nil, map[string]any{"bucket": map[string]any{}})
+	step := &Step{Diff: NewStepDiff(req, rsp)}
+
+	buf := &bytes.Buffer{}
+	if err := NewTextSink(buf).Write(&Event{Type: EventTypeStep, Payload: step}); err != nil {
+		t.Fatalf("Write(...): %v", err)
+	}
+	for _, want := range []string{"  Diff:\n", "    +++ response\n", "    +  resources:\n"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("Write(...): want output to contain %q, got:\n%s", want, buf.String())
+		}
+	}
+	if strings.Contains(buf.String(), `op:`) {
+		t.Errorf("Write(...): want no JSON Patch in text output, got:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,272 @@
+/*
+Copyright 2026 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+this file except in compliance with the License. You may obtain a copy of the
+License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed
+under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+CONDITIONS OF ANY KIND, either express or implied. See the License for the
+specific language governing permissions and limitations under the License.
+*/
+
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"maps"
+	"sync"
+	"time"
+)
+
+// Event types written when desired state changes between reconciles.
+const (
+	// EventTypeNondeterminism is the type of events written when a step
+	// returned different output for the same input as last reconcile.
+	EventTypeNondeterminism = "NONDETERMINISM"
+
+	// EventTypeDrift is the type of events written when a pipeline's final
+	// desired state differs from last reconcile.
+	EventTypeDrift = "DRIFT"
+)
+
+// DefaultDriftTTL is how long a DriftSink remembers a reconcile by default.
+const DefaultDriftTTL = time.Hour
+
+// A Drift is the payload of a NONDETERMINISM or DRIFT event. It compares a
+// reconcile with the previous reconcile of the same composite resource.
+type Drift struct {
+	// PreviousTraceID is the trace ID of the previous reconcile.
+	PreviousTraceID string
+
+	// InputHash is the hash of the step's request, which was the same in
+	// both reconciles. Only NONDETERMINISM events have an input hash.
+	InputHash string
+
+	// PreviousOutputHash and OutputHash are the hashes of the step's
+	// response, or of the pipeline's final desired state, in the previous
+	// and this reconcile.
+	PreviousOutputHash string
+	OutputHash         string
+
+	// Diff between the previous and this reconcile's desired state.
+	Diff *StepDiff
+}
+
+// MarshalJSON marshals the drift, omitting empty fields.
+func (d *Drift) MarshalJSON() ([]byte, error) {
+	drift := map[string]any{
+		"previousTraceId":    d.PreviousTraceID,
+		"previousOutputHash": d.PreviousOutputHash,
+		"outputHash":         d.OutputHash,
+	}
+	if d.InputHash != "" {
+		drift["inputHash"] = d.InputHash
+	}
+	if d.Diff != nil {
+		drift["diff"] = d.Diff.Patch
+	}
+	return json.Marshal(drift)
+}
+
+// A DriftOption configures a DriftSink.
+type DriftOption func(*DriftSink)
+
+// WithDriftTTL sets how long a reconcile is remembered. Reconciles are only
+// compared with previous reconciles that happened within this duration
+// (default: 1h).
+func WithDriftTTL(d time.Duration) DriftOption {
+	return func(s *DriftSink) {
+		s.ttl = d
+	}
+}
+
+// driftKey identifies a step of a composite resource's pipeline.
+type driftKey struct {
+	contextUID string
+	stepIndex  int32
+	iteration  int32
+}
+
+// A reconcileRecord remembers the output of a step or pipeline.
+type reconcileRecord struct {
+	traceID string
+	input   string
+	output  string
+	desired any
+	seen    time.Time
+}
+
+// A DriftSink detects composite resources whose desired state changes between
+// reconciles. It remembers a hash of the request and response of each step,
+// and of the final desired state of each pipeline run, per composite resource
+// UID. It writes all events to another sink, followed by:
+//
+//   - A NONDETERMINISM event after a STEP event whose request was identical
+//     to the step's previous request, but whose response was different.
+//   - A DRIFT event after a PIPELINE event whose final desired state was
+//     different from the previous run's.
+//
+// Steps that returned an error, and incomplete steps and pipelines, are
+// ignored.
+type DriftSink struct {
+	wrappedSink
+
+	ttl time.Duration
+	now func() time.Time
+
+	mu        sync.Mutex
+	steps     map[driftKey]reconcileRecord
+	pipelines map[string]reconcileRecord
+	pruned    time.Time
+}
+
+// NewDriftSink returns a sink that detects drift in the STEP and PIPELINE
+// events written to it.
+func NewDriftSink(s Sink, opts ...DriftOption) *DriftSink {
+	d := &DriftSink{
+		wrappedSink: wrappedSink{sink: s},
+		ttl:         DefaultDriftTTL,
+		now:         time.Now,
+		steps:       make(map[driftKey]reconcileRecord),
+		pipelines:   make(map[string]reconcileRecord),
+	}
+	for _, opt := range opts {
+		opt(d)
+	}
+	return d
+}
+
+// Write the supplied event, followed by a NONDETERMINISM or DRIFT event if
+// it reveals drift.
+func (s *DriftSink) Write(e *Event) error {
+	var warning *Event
+	switch p := e.Payload.(type) {
+	case *Step:
+		warning = s.observeStep(e, p)
+	case *Pipeline:
+		warning = s.observePipeline(e, p)
+	}
+
+	err := s.sink.Write(e)
+	if warning != nil {
+		err = errors.Join(err, s.sink.Write(warning))
+	}
+	return err
+}
+
+// observeStep remembers the supplied step, returning a NONDETERMINISM event
+// if its response differs from the previous response to the same request.
+func (s *DriftSink) observeStep(e *Event, step *Step) *Event {
+	uid := contextUID(e.Meta)
+	if e.Type != EventTypeStep || uid == "" || e.Error != "" || step.Incomplete || step.Request == nil || step.Response == nil {
+		return nil
+	}
+
+	// The request and response meta identify the call, not its input or
+	// output.
+	in, out := hashJSON(withoutField(step.Request, "meta")), hashJSON(withoutField(step.Response, "meta"))
+	if in == "" || out == "" {
+		return nil
+	}
+	desired := field(step.Response, "desired")
+	k := driftKey{contextUID: uid, stepIndex: e.Meta.GetStepIndex(), iteration: e.Meta.GetIteration()}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.prune()
+	now := s.now()
+	prev, ok := s.steps[k]
+	s.steps[k] = reconcileRecord{traceID: e.Meta.GetTraceId(), input: in, output: out, desired: desired, seen: now}
+
+	if !ok || now.Sub(prev.seen) > s.ttl || prev.input != in || prev.output == out {
+		return nil
+	}
+	return &Event{Type: EventTypeNondeterminism, Meta: e.Meta, Payload: &Drift{
+		PreviousTraceID:    prev.traceID,
+		InputHash:          in,
+		PreviousOutputHash: prev.output,
+		OutputHash:         out,
+		Diff:               NewDesiredDiff(prev.desired, desired),
+	}}
+}
+
+// observePipeline remembers the supplied pipeline run, returning a DRIFT event
+// if its final desired state differs from the previous run's.
+func (s *DriftSink) observePipeline(e *Event, pl *Pipeline) *Event {
+	uid := contextUID(e.Meta)
+	if e.Type != EventTypePipeline || uid == "" || pl.Incomplete || pl.Desired == nil {
+		return nil
+	}
+	// Crossplane doesn't apply the desired state of a pipeline that failed.
+	for _, step := range pl.Steps {
+		if step.Error != "" {
+			return nil
+		}
+	}
+
+	out := hashJSON(pl.Desired)
+	if out == "" {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.prune()
+	now := s.now()
+	prev, ok := s.pipelines[uid]
+	s.pipelines[uid] = reconcileRecord{traceID: e.Meta.GetTraceId(), output: out, desired: pl.Desired, seen: now}
+
+	if !ok || now.Sub(prev.seen) > s.ttl || prev.output == out {
+		return nil
+	}
+	return &Event{Type: EventTypeDrift, Meta: e.Meta, Payload: &Drift{
+		PreviousTraceID:    prev.traceID,
+		PreviousOutputHash: prev.output,
+		OutputHash:         out,
+		Diff:               NewDesiredDiff(prev.desired, pl.Desired),
+	}}
+}
+
+// prune forgets reconciles older than the TTL. It does nothing if it already
+// pruned within the TTL. The caller must hold the mutex.
+func (s *DriftSink) prune() {
+	now := s.now()
+	if now.Sub(s.pruned) < s.ttl {
+		return
+	}
+	s.pruned = now
+	cutoff := now.Add(-s.ttl)
+	maps.DeleteFunc(s.steps, func(_ driftKey, r reconcileRecord) bool { return r.seen.Before(cutoff) })
+	maps.DeleteFunc(s.pipelines, func(_ string, r reconcileRecord) bool { return r.seen.Before(cutoff) })
+}
+
+// hashJSON returns the hex encoded SHA-256 hash of the JSON encoding of the
+// supplied value, or an empty string if it can't be encoded. Object keys are
+// encoded in sorted order, so equal values have equal hashes.
+func hashJSON(v any) string {
+	j, err := json.Marshal(v)
+	if err != nil {
+		return ""
+	}
+	h := sha256.Sum256(j)
+	return hex.EncodeToString(h[:])
+}
+
+// withoutField returns a shallow copy of the supplied decoded JSON object
+// without the named field. Other values are returned unchanged.
+func withoutField(v any, name string) any {
+	m, ok := v.(map[string]any)
+	if !ok {
+		return v
+	}
+	c := maps.Clone(m)
+	delete(c, name)
+	return c
+}
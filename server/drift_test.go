@@ -0,0 +1,158 @@
+/*
+Copyright 2026 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+this file except in compliance with the License. You may obtain a copy of the
+License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed
+under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+CONDITIONS OF ANY KIND, either express or implied. See the License for the
+specific language governing permissions and limitations under the License.
+*/
+
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	pipelinev1alpha1 "github.com/crossplane/crossplane-runtime/v2/apis/pipelineinspector/proto/v1alpha1"
+)
+
+func driftMeta(traceID string) *pipelinev1alpha1.StepMeta {
+	return &pipelinev1alpha1.StepMeta{
+		TraceId:   traceID,
+		StepIndex: 1,
+		Context: &pipelinev1alpha1.StepMeta_CompositionMeta{CompositionMeta: &pipelinev1alpha1.CompositionMeta{
+			CompositeResourceUid: "uid-1",
+		}},
+	}
+}
+
+func TestDriftSinkNondeterminism(t *testing.T) {
+	req := func(tag, region string) map[string]any {
+		return map[string]any{
+			"meta":     map[string]any{"tag": tag},
+			"observed": map[string]any{"composite": map[string]any{"resource": map[string]any{"spec": map[string]any{"region": region}}}},
+		}
+	}
+	rsp := func(tag, name string) map[string]any {
+		return map[string]any{
+			"meta":    map[string]any{"tag": tag},
+			"desired": map[string]any{"resources": map[string]any{"bucket": map[string]any{"resource": map[string]any{"name": name}}}},
+		}
+	}
+	step := func(traceID string, req, rsp any) *Event {
+		return &Event{Type: EventTypeStep, Meta: driftMeta(traceID), Payload: &Step{Request: req, Response: rsp}}
+	}
+
+	var got []*Event
+	s := NewDriftSink(SinkFunc(func(e *Event) error {
+		got = append(got, e)
+		return nil
+	}))
+
+	for _, e := range []*Event{
+		step("trace-1", req("a", "us-east-1"), rsp("a", "bucket-1")),
+		// Same output for the same input. The meta differs, but isn't input.
+		step("trace-2", req("b", "us-east-1"), rsp("b", "bucket-1")),
+		// Different output for different input.
+		step("trace-3", req("c", "us-west-2"), rsp("c", "bucket-2")),
+		// Different output for the same input.
+		step("trace-4", req("d", "us-west-2"), rsp("d", "bucket-3")),
+	} {
+		if err := s.Write(e); err != nil {
+			t.Fatalf("Write(...): %v", err)
+		}
+	}
+
+	types := make([]string, 0, len(got))
+	for _, e := range got {
+		types = append(types, e.Type)
+	}
+	want := []string{EventTypeStep, EventTypeStep, EventTypeStep, EventTypeStep, EventTypeNondeterminism}
+	if diff := cmp.Diff(want, types); diff != "" {
+		t.Fatalf("written event types (-want +got):\n%s", diff)
+	}
+
+	drift := got[4].Payload.(*Drift)
+	if drift.PreviousTraceID != "trace-3" {
+		t.Errorf("PreviousTraceID: want trace-3, got %s", drift.PreviousTraceID)
+	}
+	if drift.InputHash == "" || drift.OutputHash == drift.PreviousOutputHash {
+		t.Errorf("want input hash and different output hashes, got %+v", drift)
+	}
+	wantPatch := []PatchOperation{{Op: PatchOpReplace, Path: "/desired/resources/bucket/resource/name", Value: "bucket-3"}}
+	if diff := cmp.Diff(wantPatch, drift.Diff.Patch); diff != "" {
+		t.Errorf("Diff.Patch (-want +got):\n%s", diff)
+	}
+}
+
+func TestDriftSinkDrift(t *testing.T) {
+	desired := func(name string) any {
+		return map[string]any{"resources": map[string]any{"bucket": map[string]any{"resource": map[string]any{"name": name}}}}
+	}
+	pipeline := func(traceID string, pl *Pipeline) *Event {
+		return &Event{Type: EventTypePipeline, Meta: driftMeta(traceID), Payload: pl}
+	}
+
+	var got []*Event
+	s := NewDriftSink(SinkFunc(func(e *Event) error {
+		got = append(got, e)
+		return nil
+	}))
+
+	for _, e := range []*Event{
+		pipeline("trace-1", &Pipeline{Desired: desired("bucket-1")}),
+		pipeline("trace-2", &Pipeline{Desired: desired("bucket-1")}),
+		// Failed and incomplete runs are ignored.
+		pipeline("trace-3", &Pipeline{Desired: desired("bucket-2"), Steps: []PipelineStep{{Error: "boom"}}}),
+		pipeline("trace-4", &Pipeline{Desired: desired("bucket-2"), Incomplete: true}),
+		pipeline("trace-5", &Pipeline{Desired: desired("bucket-2")}),
+	} {
+		if err := s.Write(e); err != nil {
+			t.Fatalf("Write(...): %v", err)
+		}
+	}
+
+	if len(got) != 6 || got[5].Type != EventTypeDrift {
+		t.Fatalf("want 5 PIPELINE events followed by a DRIFT event, got %d events", len(got))
+	}
+	drift := got[5].Payload.(*Drift)
+	if drift.PreviousTraceID != "trace-2" {
+		t.Errorf("PreviousTraceID: want trace-2, got %s", drift.PreviousTraceID)
+	}
+	if drift.InputHash != "" {
+		t.Errorf("InputHash: want empty for DRIFT, got %s", drift.InputHash)
+	}
+	wantPatch := []PatchOperation{{Op: PatchOpReplace, Path: "/desired/resources/bucket/resource/name", Value: "bucket-2"}}
+	if diff := cmp.Diff(wantPatch, drift.Diff.Patch); diff != "" {
+		t.Errorf("Diff.Patch (-want +got):\n%s", diff)
+	}
+}
+
+func TestDriftSinkTTL(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	var got []*Event
+	s := NewDriftSink(SinkFunc(func(e *Event) error {
+		got = append(got, e)
+		return nil
+	}), WithDriftTTL(time.Minute))
+	s.now = func() time.Time { return now }
+
+	_ = s.Write(&Event{Type: EventTypePipeline, Meta: driftMeta("trace-1"), Payload: &Pipeline{Desired: map[string]any{"a": 1.0}}})
+	now = now.Add(2 * time.Minute)
+	_ = s.Write(&Event{Type: EventTypePipeline, Meta: driftMeta("trace-2"), Payload: &Pipeline{Desired: map[string]any{"a": 2.0}}})
+
+	if len(got) != 2 {
+		t.Errorf("want no DRIFT event after the TTL, got %d events", len(got))
+	}
+	if len(s.pipelines) != 1 {
+		t.Errorf("want expired reconciles pruned, got %d remembered", len(s.pipelines))
+	}
+}
@@ -0,0 +1,128 @@
+/*
+Copyright 2026 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+this file except in compliance with the License. You may obtain a copy of the
+License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed
+under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+CONDITIONS OF ANY KIND, either express or implied. See the License for the
+specific language governing permissions and limitations under the License.
+*/
+
+package server
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// A FanOutSink writes each event to several sinks.
+type FanOutSink struct {
+	sinks []Sink
+}
+
+// NewFanOutSink returns a sink that writes each event to all of the supplied
+// sinks.
+func NewFanOutSink(sinks ...Sink) *FanOutSink {
+	return &FanOutSink{sinks: sinks}
+}
+
+// Write the supplied event to every sink. A sink that fails to write the event
+// doesn't prevent the event being written to the remaining sinks. The returned
+// error joins the errors returned by all failing sinks.
+func (s *FanOutSink) Write(e *Event) error {
+	var errs []error
+	for idx, sink := range s.sinks {
+		if err := sink.Write(e); err != nil {
+			errs = append(errs, fmt.Errorf("sink %d: %w", idx, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Close all sinks that implement io.Closer.
+func (s *FanOutSink) Close() error {
+	var errs []error
+	for idx, sink := range s.sinks {
+		if err := CloseSink(sink); err != nil {
+			errs = append(errs, fmt.Errorf("sink %d: %w", idx, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Sync all sinks that support syncing to stable storage.
+func (s *FanOutSink) Sync() error {
+	var errs []error
+	for idx, sink := range s.sinks {
+		if err := syncSink(sink); err != nil {
+			errs = append(errs, fmt.Errorf("sink %d: %w", idx, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Check that all sinks with a Check method can write events.
+func (s *FanOutSink) Check() error {
+	var errs []error
+	for idx, sink := range s.sinks {
+		if err := checkSink(sink); err != nil {
+			errs = append(errs, fmt.Errorf("sink %d: %w", idx, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// A wrappedSink is embedded by sinks that wrap another sink. It forwards
+// Close, Sync and Check to the wrapped sink.
+type wrappedSink struct {
+	sink Sink
+}
+
+// Close the wrapped sink if it implements io.Closer.
+func (w wrappedSink) Close() error {
+	return CloseSink(w.sink)
+}
+
+// Sync the wrapped sink if it has a Sync method.
+func (w wrappedSink) Sync() error {
+	return syncSink(w.sink)
+}
+
+// Check whether the wrapped sink can write events, if it has a Check method.
+func (w wrappedSink) Check() error {
+	return checkSink(w.sink)
+}
+
+// CloseSink closes the supplied sink if it implements io.Closer.
+func CloseSink(s Sink) error {
+	c, ok := s.(io.Closer)
+	if !ok {
+		return nil
+	}
+	return c.Close()
+}
+
+// syncSink syncs the supplied sink if it has a Sync method.
+func syncSink(s Sink) error {
+	sy, ok := s.(interface{ Sync() error })
+	if !ok {
+		return nil
+	}
+	return sy.Sync()
+}
+
+// checkSink checks whether the supplied sink can write events, if it has a
+// Check method. Sinks without one are assumed to be able to.
+func checkSink(s Sink) error {
+	c, ok := s.(interface{ Check() error })
+	if !ok {
+		return nil
+	}
+	return c.Check()
+}
@@ -0,0 +1,70 @@
+/*
+Copyright 2026 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+this file except in compliance with the License. You may obtain a copy of the
+License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed
+under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+CONDITIONS OF ANY KIND, either express or implied. See the License for the
+specific language governing permissions and limitations under the License.
+*/
+
+package server
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	pipelinev1alpha1 "github.com/crossplane/crossplane-runtime/v2/apis/pipelineinspector/proto/v1alpha1"
+)
+
+type closeRecorder struct {
+	Sink
+
+	closed bool
+}
+
+func (c *closeRecorder) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestFanOutSink(t *testing.T) {
+	var jsonOut, textOut bytes.Buffer
+	failing := SinkFunc(func(_ *Event) error {
+		return errors.New("boom")
+	})
+	s := NewFanOutSink(failing, NewJSONSink(&jsonOut), NewTextSink(&textOut))
+
+	err := s.Write(&Event{Type: EventTypeRequest, Meta: &pipelinev1alpha1.StepMeta{FunctionName: "my-function"}})
+	if err == nil || !strings.Contains(err.Error(), "sink 0: boom") {
+		t.Errorf("expected error from failing sink, got: %v", err)
+	}
+
+	// A failing sink must not prevent the others receiving the event.
+	if !strings.Contains(jsonOut.String(), `"functionName":"my-function"`) {
+		t.Errorf("expected JSON sink to receive event, got: %s", jsonOut.String())
+	}
+	if !strings.Contains(textOut.String(), "  Function:    my-function\n") {
+		t.Errorf("expected text sink to receive event, got: %s", textOut.String())
+	}
+}
+
+func TestFanOutSink_Close(t *testing.T) {
+	a := &closeRecorder{Sink: NewJSONSink(&bytes.Buffer{})}
+	b := &closeRecorder{Sink: NewJSONSink(&bytes.Buffer{})}
+	s := NewFanOutSink(a, NewJSONSink(&bytes.Buffer{}), NewFilteredSink(b, MatchEventTypes()))
+
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if !a.closed || !b.closed {
+		t.Errorf("expected all closable sinks to be closed, got a=%t b=%t", a.closed, b.closed)
+	}
+}
@@ -0,0 +1,429 @@
+/*
+Copyright 2026 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+this file except in compliance with the License. You may obtain a copy of the
+License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed
+under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+CONDITIONS OF ANY KIND, either express or implied. See the License for the
+specific language governing permissions and limitations under the License.
+*/
+
+package server
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/crossplane/crossplane-runtime/v2/pkg/logging"
+)
+
+// rotatedTimeFormat is the timestamp format used to name rotated segments. It
+// sorts lexically in time order.
+const rotatedTimeFormat = "20060102T150405.000"
+
+// A FileOption configures a FileSink.
+type FileOption func(*RotatingFile)
+
+// WithMaxFileSize rotates the active file before a write would grow it beyond
+// the supplied number of bytes. Zero disables size-based rotation.
+func WithMaxFileSize(bytes int64) FileOption {
+	return func(f *RotatingFile) {
+		f.maxSize = bytes
+	}
+}
+
+// WithMaxFileAge rotates the active file once it has been open for longer
+// than the supplied duration. Zero disables age-based rotation.
+func WithMaxFileAge(d time.Duration) FileOption {
+	return func(f *RotatingFile) {
+		f.maxAge = d
+	}
+}
+
+// WithFileRetention deletes rotated segments that are older than the supplied
+// duration. Zero keeps rotated segments regardless of age.
+func WithFileRetention(d time.Duration) FileOption {
+	return func(f *RotatingFile) {
+		f.retention = d
+	}
+}
+
+// WithMaxTotalFileSize deletes the oldest rotated segments until the active
+// file and all rotated segments use no more than the supplied number of bytes.
+// Zero disables the disk budget.
+func WithMaxTotalFileSize(bytes int64) FileOption {
+	return func(f *RotatingFile) {
+		f.maxTotalSize = bytes
+	}
+}
+
+// WithFileCompression gzips rotated segments.
+func WithFileCompression(compress bool) FileOption {
+	return func(f *RotatingFile) {
+		f.compress = compress
+	}
+}
+
+// WithFileLogger sets the logger used to report rotation errors.
+func WithFileLogger(l logging.Logger) FileOption {
+	return func(f *RotatingFile) {
+		f.log = l
+	}
+}
+
+// A RotatingFile is an io.WriteCloser that appends to a file, rotating it by
+// size and age. Rotated segments are kept alongside the active file, named
+// after it with the time of rotation inserted before the extension, e.g.
+// events-20260115T103000.000.json. Segments rotated within the same
+// millisecond get a sequence number, e.g. events-20260115T103000.000-1.json.
+// Rotated segments are compressed in the background.
+type RotatingFile struct {
+	path         string
+	maxSize      int64
+	maxAge       time.Duration
+	retention    time.Duration
+	maxTotalSize int64
+	compress     bool
+	now          func() time.Time
+	rename       func(from, to string) error
+	log          logging.Logger
+
+	mu     sync.Mutex
+	f      *os.File
+	size   int64
+	opened time.Time
+	err    error
+
+	compressing sync.WaitGroup
+}
+
+// NewRotatingFile opens the supplied file for appending, creating it and its
+// directory if necessary.
+func NewRotatingFile(path string, opts ...FileOption) (*RotatingFile, error) {
+	f := &RotatingFile{path: path, now: time.Now, rename: os.Rename, log: logging.NewNopLogger()}
+	for _, opt := range opts {
+		opt(f)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return nil, fmt.Errorf("cannot create directory: %w", err)
+	}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	if err := f.prune(); err != nil {
+		_ = f.f.Close()
+		return nil, err
+	}
+	return f, nil
+}
+
+// Write the supplied bytes to the active file, rotating it first if needed.
+// Each call is written to a single segment. If the file can't be rotated the
+// bytes are written to the active file, and rotation is retried on the next
+// write.
+func (f *RotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.f == nil {
+		return 0, os.ErrClosed
+	}
+	if f.shouldRotate(int64(len(p))) {
+		if err := f.rotate(); err != nil {
+			f.log.Info("Cannot rotate file", "path", f.path, "error", err)
+		}
+	}
+
+	n, err := f.f.Write(p)
+	f.size += int64(n)
+	f.err = err
+	return n, err
+}
+
+// Check returns an error if the file is closed, or if the last write failed.
+func (f *RotatingFile) Check() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.f == nil {
+		return os.ErrClosed
+	}
+	if f.err != nil {
+		return fmt.Errorf("last write failed: %w", f.err)
+	}
+	return nil
+}
+
+// Sync commits the active file to stable storage.
+func (f *RotatingFile) Sync() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.f == nil {
+		return nil
+	}
+	return f.f.Sync()
+}
+
+// Close syncs and closes the active file, then waits for rotated segments to
+// be compressed.
+func (f *RotatingFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.f == nil {
+		return nil
+	}
+	err := errors.Join(f.f.Sync(), f.f.Close())
+	f.f = nil
+	f.compressing.Wait()
+	return err
+}
+
+func (f *RotatingFile) shouldRotate(n int64) bool {
+	if f.size == 0 {
+		return false
+	}
+	if f.maxSize > 0 && f.size+n > f.maxSize {
+		return true
+	}
+	return f.maxAge > 0 && f.now().Sub(f.opened) >= f.maxAge
+}
+
+func (f *RotatingFile) open() error {
+	file, err := os.OpenFile(f.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o640)
+	if err != nil {
+		return fmt.Errorf("cannot open file: %w", err)
+	}
+	fi, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return fmt.Errorf("cannot stat file: %w", err)
+	}
+	f.f = file
+	f.size = fi.Size()
+	f.opened = f.now()
+	return nil
+}
+
+// rotate renames the active file to a rotated segment and opens a new active
+// file, then prunes rotated segments. If rotation fails the active file stays
+// open, so a later write can retry. Pruning errors are logged rather than
+// returned, because the file was rotated.
+func (f *RotatingFile) rotate() error {
+	if err := f.f.Sync(); err != nil {
+		return fmt.Errorf("cannot sync file: %w", err)
+	}
+
+	// Rename the file while it's open, so we can keep writing to it if we
+	// can't open a new one.
+	// If the file was deleted there's nothing to rename, but we still need a
+	// new one.
+	rotated := f.rotatedName(f.now())
+	renamed := true
+	if err := f.rename(f.path, rotated); err != nil {
+		if !os.IsNotExist(err) {
+			return fmt.Errorf("cannot rotate file: %w", err)
+		}
+		renamed = false
+	}
+	old := f.f
+	if err := f.open(); err != nil {
+		// Keep writing to the old file, under its original name.
+		if renamed {
+			_ = f.rename(rotated, f.path)
+		}
+		return err
+	}
+	// The old file was synced, so closing it can't lose writes.
+	_ = old.Close()
+
+	if f.compress && renamed {
+		f.compressing.Add(1)
+		go func() {
+			defer f.compressing.Done()
+			// If compression fails the segment is kept uncompressed. It's
+			// pruned like any other segment.
+			_ = gzipFile(rotated)
+		}()
+	}
+	if err := f.prune(); err != nil {
+		f.log.Info("Cannot prune rotated files", "path", f.path, "error", err)
+	}
+	return nil
+}
+
+// rotatedName returns an unused name for a segment rotated at the supplied
+// time.
+func (f *RotatingFile) rotatedName(t time.Time) string {
+	ext := filepath.Ext(f.path)
+	base := strings.TrimSuffix(f.path, ext) + "-" + t.UTC().Format(rotatedTimeFormat)
+	for seq := 0; ; seq++ {
+		name := base + ext
+		if seq > 0 {
+			name = base + "-" + strconv.Itoa(seq) + ext
+		}
+		if !exists(name) && !exists(name+".gz") {
+			return name
+		}
+	}
+}
+
+// exists returns true if the supplied path exists.
+func exists(path string) bool {
+	_, err := os.Lstat(path)
+	return err == nil
+}
+
+// prune deletes rotated segments that are older than the retention period or
+// exceed the disk budget, oldest first.
+func (f *RotatingFile) prune() error {
+	if f.retention == 0 && f.maxTotalSize == 0 {
+		return nil
+	}
+
+	ext := filepath.Ext(f.path)
+	prefix := strings.TrimSuffix(filepath.Base(f.path), ext) + "-"
+	entries, err := os.ReadDir(filepath.Dir(f.path))
+	if err != nil {
+		return fmt.Errorf("cannot list rotated files: %w", err)
+	}
+
+	type segment struct {
+		path    string
+		size    int64
+		rotated time.Time
+		seq     int
+	}
+	var segments []segment
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		ts := strings.TrimSuffix(strings.TrimSuffix(strings.TrimPrefix(name, prefix), ".gz"), ext)
+		ts, suffix, hasSeq := strings.Cut(ts, "-")
+		rotated, err := time.Parse(rotatedTimeFormat, ts)
+		if err != nil {
+			continue
+		}
+		seq := 0
+		if hasSeq {
+			if seq, err = strconv.Atoi(suffix); err != nil {
+				continue
+			}
+		}
+		fi, err := e.Info()
+		if err != nil {
+			continue
+		}
+		segments = append(segments, segment{path: filepath.Join(filepath.Dir(f.path), name), size: fi.Size(), rotated: rotated, seq: seq})
+	}
+
+	// Newest first, so we keep as many recent segments as the budget allows.
+	slices.SortFunc(segments, func(a, b segment) int {
+		if c := b.rotated.Compare(a.rotated); c != 0 {
+			return c
+		}
+		return b.seq - a.seq
+	})
+
+	var errs []error
+	total := f.size
+	for _, s := range segments {
+		total += s.size
+		expired := f.retention > 0 && f.now().Sub(s.rotated) > f.retention
+		overBudget := f.maxTotalSize > 0 && total > f.maxTotalSize
+		if !expired && !overBudget {
+			continue
+		}
+		if err := os.Remove(s.path); err != nil && !os.IsNotExist(err) {
+			errs = append(errs, err)
+		}
+		total -= s.size
+	}
+	return errors.Join(errs...)
+}
+
+// gzipFile compresses the supplied file to a .gz file and removes the original.
+func gzipFile(path string) error {
+	in, err := os.Open(path) //nolint:gosec // We're opening a file we rotated.
+	if err != nil {
+		return err
+	}
+	defer func() { _ = in.Close() }()
+
+	tmp := path + ".gz.tmp"
+	out, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o640) //nolint:gosec // We're creating a file next to one we rotated.
+	if err != nil {
+		return err
+	}
+	zw := gzip.NewWriter(out)
+	if _, err := io.Copy(zw, in); err != nil {
+		_ = out.Close()
+		_ = os.Remove(tmp)
+		return err
+	}
+	if err := errors.Join(zw.Close(), out.Sync(), out.Close()); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, path+".gz"); err != nil {
+		return err
+	}
+	// The segment may have been pruned while we compressed it.
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// A FileSink writes events to a RotatingFile.
+type FileSink struct {
+	sink Sink
+	file *RotatingFile
+}
+
+// NewFileSink returns a sink that writes events in the supplied format to the
+// supplied file, rotating it according to the supplied options.
+func NewFileSink(path, format string, opts ...FileOption) (*FileSink, error) {
+	f, err := NewRotatingFile(path, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &FileSink{sink: NewFormatSink(format, f), file: f}, nil
+}
+
+// Write the supplied event to the file.
+func (s *FileSink) Write(e *Event) error {
+	return s.sink.Write(e)
+}
+
+// Sync commits the file to stable storage.
+func (s *FileSink) Sync() error {
+	return s.file.Sync()
+}
+
+// Check returns an error if the file is closed, or if the last write failed.
+func (s *FileSink) Check() error {
+	return s.file.Check()
+}
+
+// Close syncs and closes the file.
+func (s *FileSink) Close() error {
+	return s.file.Close()
+}
@@ -0,0 +1,314 @@
+/*
+Copyright 2026 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+this file except in compliance with the License. You may obtain a copy of the
+License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed
+under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+CONDITIONS OF ANY KIND, either express or implied. See the License for the
+specific language governing permissions and limitations under the License.
+*/
+
+package server
+
+import (
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	pipelinev1alpha1 "github.com/crossplane/crossplane-runtime/v2/apis/pipelineinspector/proto/v1alpha1"
+)
+
+// fakeClock returns a clock function that starts at a fixed time and can be
+// advanced.
+func fakeClock() (func() time.Time, func(time.Duration)) {
+	now := time.Date(2026, 1, 15, 10, 30, 0, 0, time.UTC)
+	return func() time.Time { return now }, func(d time.Duration) { now = now.Add(d) }
+}
+
+func listDir(t *testing.T, dir string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("cannot list directory: %v", err)
+	}
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	slices.Sort(names)
+	return names
+}
+
+func TestRotatingFile_MaxSize(t *testing.T) {
+	dir := t.TempDir()
+	now, advance := fakeClock()
+	f, err := NewRotatingFile(filepath.Join(dir, "events.json"), WithMaxFileSize(10))
+	if err != nil {
+		t.Fatalf("NewRotatingFile failed: %v", err)
+	}
+	f.now = now
+
+	for _, line := range []string{"aaaaaa\n", "bbbbbb\n", "cccccc\n"} {
+		if _, err := f.Write([]byte(line)); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+		advance(time.Second)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	want := []string{"events-20260115T103001.000.json", "events-20260115T103002.000.json", "events.json"}
+	if diff := cmp.Diff(want, listDir(t, dir)); diff != "" {
+		t.Errorf("files mismatch (-want +got):\n%s", diff)
+	}
+
+	got, _ := os.ReadFile(filepath.Join(dir, "events.json"))
+	if string(got) != "cccccc\n" {
+		t.Errorf("expected active file to contain the last write, got: %q", got)
+	}
+}
+
+func TestRotatingFile_MaxAge(t *testing.T) {
+	dir := t.TempDir()
+	now, advance := fakeClock()
+	f, err := NewRotatingFile(filepath.Join(dir, "events.json"), WithMaxFileAge(time.Hour))
+	if err != nil {
+		t.Fatalf("NewRotatingFile failed: %v", err)
+	}
+	f.now = now
+	f.opened = now()
+
+	_, _ = f.Write([]byte("a\n"))
+	advance(30 * time.Minute)
+	_, _ = f.Write([]byte("b\n"))
+	advance(30 * time.Minute)
+	_, _ = f.Write([]byte("c\n"))
+	_ = f.Close()
+
+	want := []string{"events-20260115T113000.000.json", "events.json"}
+	if diff := cmp.Diff(want, listDir(t, dir)); diff != "" {
+		t.Errorf("files mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestRotatingFile_Compress(t *testing.T) {
+	dir := t.TempDir()
+	now, _ := fakeClock()
+	f, err := NewRotatingFile(filepath.Join(dir, "events.json"), WithMaxFileSize(4), WithFileCompression(true))
+	if err != nil {
+		t.Fatalf("NewRotatingFile failed: %v", err)
+	}
+	f.now = now
+
+	_, _ = f.Write([]byte("one\n"))
+	_, _ = f.Write([]byte("two\n"))
+	_ = f.Close()
+
+	want := []string{"events-20260115T103000.000.json.gz", "events.json"}
+	if diff := cmp.Diff(want, listDir(t, dir)); diff != "" {
+		t.Fatalf("files mismatch (-want +got):\n%s", diff)
+	}
+
+	gz, err := os.Open(filepath.Join(dir, want[0]))
+	if err != nil {
+		t.Fatalf("cannot open rotated file: %v", err)
+	}
+	defer func() { _ = gz.Close() }()
+	zr, err := gzip.NewReader(gz)
+	if err != nil {
+		t.Fatalf("cannot read gzip: %v", err)
+	}
+	got, _ := io.ReadAll(zr)
+	if string(got) != "one\n" {
+		t.Errorf("expected rotated segment to contain first write, got: %q", got)
+	}
+}
+
+func TestRotatingFile_SameMillisecond(t *testing.T) {
+	dir := t.TempDir()
+	now, _ := fakeClock()
+	f, err := NewRotatingFile(filepath.Join(dir, "events.json"), WithMaxFileSize(4), WithMaxTotalFileSize(8))
+	if err != nil {
+		t.Fatalf("NewRotatingFile failed: %v", err)
+	}
+	f.now = now
+
+	for _, line := range []string{"aaa\n", "bbb\n", "ccc\n", "ddd\n"} {
+		if _, err := f.Write([]byte(line)); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+	}
+	_ = f.Close()
+
+	// The oldest segment is pruned to fit the budget.
+	want := []string{"events-20260115T103000.000-1.json", "events-20260115T103000.000-2.json", "events.json"}
+	if diff := cmp.Diff(want, listDir(t, dir)); diff != "" {
+		t.Fatalf("files mismatch (-want +got):\n%s", diff)
+	}
+	got, _ := os.ReadFile(filepath.Join(dir, want[0]))
+	if string(got) != "bbb\n" {
+		t.Errorf("expected first kept segment to contain the second write, got: %q", got)
+	}
+}
+
+func TestRotatingFile_ActiveFileDeleted(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "events.json")
+	f, err := NewRotatingFile(path, WithMaxFileSize(4))
+	if err != nil {
+		t.Fatalf("NewRotatingFile failed: %v", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	_, _ = f.Write([]byte("one\n"))
+	if err := os.Remove(path); err != nil {
+		t.Fatalf("cannot remove active file: %v", err)
+	}
+	if _, err := f.Write([]byte("two\n")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if err := f.Check(); err != nil {
+		t.Errorf("Check failed: %v", err)
+	}
+	got, _ := os.ReadFile(path)
+	if string(got) != "two\n" {
+		t.Errorf("expected a new active file with the second write, got: %q", got)
+	}
+}
+
+func TestRotatingFile_RotateFails(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "events.json")
+	f, err := NewRotatingFile(path, WithMaxFileSize(4))
+	if err != nil {
+		t.Fatalf("NewRotatingFile failed: %v", err)
+	}
+	defer func() { _ = f.Close() }()
+	f.rename = func(string, string) error { return errors.New("boom") }
+
+	// Writes that can't be rotated go to the active file.
+	for _, line := range []string{"one\n", "two\n"} {
+		if _, err := f.Write([]byte(line)); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+	}
+	if err := f.Check(); err != nil {
+		t.Errorf("Check failed: %v", err)
+	}
+	want := []string{"events.json"}
+	if diff := cmp.Diff(want, listDir(t, dir)); diff != "" {
+		t.Fatalf("files mismatch (-want +got):\n%s", diff)
+	}
+	got, _ := os.ReadFile(path)
+	if string(got) != "one\ntwo\n" {
+		t.Errorf("expected both writes in the active file, got: %q", got)
+	}
+}
+
+func TestRotatingFile_Retention(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []FileOption
+		want []string
+	}{
+		{
+			name: "max total size keeps newest segments",
+			opts: []FileOption{WithMaxFileSize(4), WithMaxTotalFileSize(12)},
+			want: []string{
+				"events-20260115T103501.000.json",
+				"events-20260115T103502.000.json",
+				"events-20260115T103503.000.json",
+				"events.json",
+			},
+		},
+		{
+			name: "retention deletes old segments",
+			opts: []FileOption{WithMaxFileSize(4), WithFileRetention(90 * time.Second)},
+			want: []string{
+				"events-20260115T103400.000.json",
+				"events-20260115T103501.000.json",
+				"events-20260115T103502.000.json",
+				"events-20260115T103503.000.json",
+				"events.json",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			now, advance := fakeClock()
+			f, err := NewRotatingFile(filepath.Join(dir, "events.json"), tt.opts...)
+			if err != nil {
+				t.Fatalf("NewRotatingFile failed: %v", err)
+			}
+			f.now = now
+
+			// Every write after the first rotates the active file. Write five
+			// times a minute apart, then three times a second apart.
+			for range 5 {
+				_, _ = f.Write([]byte("xxx\n"))
+				advance(time.Minute)
+			}
+			for range 3 {
+				advance(time.Second)
+				_, _ = f.Write([]byte("xxx\n"))
+			}
+			_ = f.Close()
+
+			if diff := cmp.Diff(tt.want, listDir(t, dir)); diff != "" {
+				t.Errorf("files mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestFileSink(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "nested", "events.json")
+	s, err := NewFileSink(path, FormatJSON)
+	if err != nil {
+		t.Fatalf("NewFileSink failed: %v", err)
+	}
+
+	if err := s.Write(&Event{Type: EventTypeRequest, Meta: &pipelinev1alpha1.StepMeta{FunctionName: "my-function"}}); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if err := s.Sync(); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	if err := s.Check(); err != nil {
+		t.Errorf("Check failed: %v", err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if err := s.Write(&Event{Type: EventTypeRequest}); err == nil {
+		t.Error("expected Write after Close to fail")
+	}
+	if err := s.Check(); err == nil {
+		t.Error("expected Check after Close to fail")
+	}
+
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("cannot read file: %v", err)
+	}
+	if !strings.Contains(string(got), `"functionName":"my-function"`) {
+		t.Errorf("expected event in file, got: %s", got)
+	}
+}
@@ -0,0 +1,93 @@
+/*
+Copyright 2026 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+this file except in compliance with the License. You may obtain a copy of the
+License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed
+under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+CONDITIONS OF ANY KIND, either express or implied. See the License for the
+specific language governing permissions and limitations under the License.
+*/
+
+package server
+
+import (
+	"slices"
+)
+
+// A Filter decides whether an event should be written to a sink.
+type Filter interface {
+	// Match returns true if the supplied event should be written.
+	Match(e *Event) bool
+}
+
+// A FilterFunc is a function that satisfies the Filter interface.
+type FilterFunc func(e *Event) bool
+
+// Match the supplied event by calling the function.
+func (fn FilterFunc) Match(e *Event) bool {
+	return fn(e)
+}
+
+// MatchEventTypes returns a filter that matches events of any of the supplied
+// types. It matches all events if no types are supplied.
+func MatchEventTypes(types ...string) Filter {
+	return FilterFunc(func(e *Event) bool {
+		return len(types) == 0 || slices.Contains(types, e.Type)
+	})
+}
+
+// MatchAll returns a filter that matches events that match all of the supplied
+// filters. Filters are evaluated in order, and evaluation stops at the first
+// filter that doesn't match.
+func MatchAll(filters ...Filter) Filter {
+	return FilterFunc(func(e *Event) bool {
+		for _, f := range filters {
+			if !f.Match(e) {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// An IncludeExcludeFilter matches events that match any of its include
+// filters, unless they also match any of its exclude filters. It includes all
+// events if it has no include filters.
+type IncludeExcludeFilter struct {
+	Include []Filter
+	Exclude []Filter
+}
+
+// Match returns true if the supplied event is included and not excluded.
+func (f *IncludeExcludeFilter) Match(e *Event) bool {
+	if len(f.Include) > 0 && !slices.ContainsFunc(f.Include, func(i Filter) bool { return i.Match(e) }) {
+		return false
+	}
+	return !slices.ContainsFunc(f.Exclude, func(x Filter) bool { return x.Match(e) })
+}
+
+// A FilteredSink writes only events that match a filter to another sink.
+type FilteredSink struct {
+	wrappedSink
+
+	filter Filter
+}
+
+// NewFilteredSink returns a sink that writes events matching the supplied
+// filter to the supplied sink, and discards all other events.
+func NewFilteredSink(s Sink, f Filter) *FilteredSink {
+	return &FilteredSink{wrappedSink: wrappedSink{sink: s}, filter: f}
+}
+
+// Write the supplied event if it matches the filter.
+func (s *FilteredSink) Write(e *Event) error {
+	if !s.filter.Match(e) {
+		return nil
+	}
+	return s.sink.Write(e)
+}
@@ -0,0 +1,87 @@
+/*
+Copyright 2026 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+this file except in compliance with the License. You may obtain a copy of the
+License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed
+under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+CONDITIONS OF ANY KIND, either express or implied. See the License for the
+specific language governing permissions and limitations under the License.
+*/
+
+package server
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestFilteredSink(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter Filter
+		events []string
+		want   []string
+	}{
+		{
+			name:   "no types matches everything",
+			filter: MatchEventTypes(),
+			events: []string{EventTypeRequest, EventTypeResponse},
+			want:   []string{EventTypeRequest, EventTypeResponse},
+		},
+		{
+			name:   "only responses",
+			filter: MatchEventTypes(EventTypeResponse),
+			events: []string{EventTypeRequest, EventTypeResponse, EventTypeRequest},
+			want:   []string{EventTypeResponse},
+		},
+		{
+			name:   "include any",
+			filter: &IncludeExcludeFilter{Include: []Filter{MatchEventTypes(EventTypeRequest), MatchEventTypes(EventTypeStep)}},
+			events: []string{EventTypeRequest, EventTypeResponse, EventTypeStep},
+			want:   []string{EventTypeRequest, EventTypeStep},
+		},
+		{
+			name:   "exclude wins",
+			filter: &IncludeExcludeFilter{Include: []Filter{MatchEventTypes(EventTypeRequest, EventTypeResponse)}, Exclude: []Filter{MatchEventTypes(EventTypeRequest)}},
+			events: []string{EventTypeRequest, EventTypeResponse},
+			want:   []string{EventTypeResponse},
+		},
+		{
+			name:   "no includes matches everything not excluded",
+			filter: &IncludeExcludeFilter{Exclude: []Filter{MatchEventTypes(EventTypeResponse)}},
+			events: []string{EventTypeRequest, EventTypeResponse},
+			want:   []string{EventTypeRequest},
+		},
+		{
+			name:   "filter func",
+			filter: FilterFunc(func(_ *Event) bool { return false }),
+			events: []string{EventTypeRequest, EventTypeResponse},
+			want:   nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			sink := SinkFunc(func(e *Event) error {
+				got = append(got, e.Type)
+				return nil
+			})
+			s := NewFilteredSink(sink, tt.filter)
+			for _, typ := range tt.events {
+				if err := s.Write(&Event{Type: typ}); err != nil {
+					t.Fatalf("Write failed: %v", err)
+				}
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("written events mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,160 @@
+/*
+Copyright 2026 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+this file except in compliance with the License. You may obtain a copy of the
+License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed
+under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+CONDITIONS OF ANY KIND, either express or implied. See the License for the
+specific language governing permissions and limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+
+	pipelinev1alpha1 "github.com/crossplane/crossplane-runtime/v2/apis/pipelineinspector/proto/v1alpha1"
+)
+
+// Health check paths.
+const (
+	PathHealthz = "/healthz"
+	PathReadyz  = "/readyz"
+)
+
+var errNotServing = errors.New("not serving on the inspector socket")
+
+// A HealthOption configures a Health.
+type HealthOption func(*Health)
+
+// WithCheckInterval sets how often Run checks readiness and updates the gRPC
+// health service (default: 10s).
+func WithCheckInterval(d time.Duration) HealthOption {
+	return func(h *Health) {
+		h.interval = d
+	}
+}
+
+// A Health reports whether the Inspector is ready to capture events. It's
+// ready once it's serving on its socket, as long as all of its sinks can write
+// events.
+//
+// Health serves readiness over HTTP, and over the standard gRPC health
+// service. The gRPC health service reports the status of both the server as a
+// whole and the PipelineInspectorService.
+type Health struct {
+	sink     Sink
+	interval time.Duration
+	serving  atomic.Bool
+	stopped  atomic.Bool
+	grpc     *health.Server
+}
+
+// NewHealth returns a Health that checks the supplied sink. It isn't ready
+// until SetServing is called.
+func NewHealth(s Sink, opts ...HealthOption) *Health {
+	h := &Health{
+		sink:     s,
+		interval: 10 * time.Second,
+		grpc:     health.NewServer(),
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	h.setStatus(healthpb.HealthCheckResponse_NOT_SERVING)
+	return h
+}
+
+// Register the gRPC health service with the supplied server.
+func (h *Health) Register(s *grpc.Server) {
+	healthpb.RegisterHealthServer(s, h.grpc)
+}
+
+// SetServing records whether the Inspector is serving on its socket. Once set
+// to false the Inspector is never ready again, because it's shutting down.
+func (h *Health) SetServing(serving bool) {
+	if !serving {
+		h.stopped.Store(true)
+		h.serving.Store(false)
+		h.grpc.Shutdown()
+		return
+	}
+	if h.stopped.Load() {
+		return
+	}
+	h.serving.Store(true)
+	_ = h.Check()
+}
+
+// Check returns an error if the Inspector isn't ready, and updates the status
+// reported by the gRPC health service.
+func (h *Health) Check() error {
+	err := h.ready()
+	status := healthpb.HealthCheckResponse_SERVING
+	if err != nil {
+		status = healthpb.HealthCheckResponse_NOT_SERVING
+	}
+	h.setStatus(status)
+	return err
+}
+
+// Run checks readiness periodically, until the supplied context is done, so
+// the gRPC health service notices when sinks stop being able to write.
+func (h *Health) Run(ctx context.Context) {
+	t := time.NewTicker(h.interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			_ = h.Check()
+		}
+	}
+}
+
+// ServeHTTP serves liveness at PathHealthz and readiness at PathReadyz.
+// Liveness only reports that the process can serve HTTP requests.
+func (h *Health) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	switch req.URL.Path {
+	case PathHealthz:
+		_, _ = fmt.Fprintln(w, "ok")
+	case PathReadyz:
+		if err := h.Check(); err != nil {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = fmt.Fprintln(w, "ok")
+	default:
+		http.NotFound(w, req)
+	}
+}
+
+func (h *Health) ready() error {
+	if !h.serving.Load() {
+		return errNotServing
+	}
+	if err := checkSink(h.sink); err != nil {
+		return fmt.Errorf("cannot write events: %w", err)
+	}
+	return nil
+}
+
+func (h *Health) setStatus(status healthpb.HealthCheckResponse_ServingStatus) {
+	h.grpc.SetServingStatus("", status)
+	h.grpc.SetServingStatus(pipelinev1alpha1.PipelineInspectorService_ServiceDesc.ServiceName, status)
+}
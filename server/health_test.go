@@ -0,0 +1,149 @@
+/*
+Copyright 2026 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+this file except in compliance with the License. You may obtain a copy of the
+License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed
+under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+CONDITIONS OF ANY KIND, either express or implied. See the License for the
+specific language governing permissions and limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+
+	pipelinev1alpha1 "github.com/crossplane/crossplane-runtime/v2/apis/pipelineinspector/proto/v1alpha1"
+)
+
+type checkFunc func() error
+
+func (fn checkFunc) Write(*Event) error { return nil }
+func (fn checkFunc) Check() error       { return fn() }
+
+func TestHealthServeHTTP(t *testing.T) {
+	type want struct {
+		status int
+	}
+	cases := map[string]struct {
+		reason  string
+		path    string
+		serving bool
+		check   error
+		want    want
+	}{
+		"Live": {
+			reason: "The sidecar should be live even if it isn't ready.",
+			path:   PathHealthz,
+			want:   want{status: http.StatusOK},
+		},
+		"NotServing": {
+			reason: "The sidecar shouldn't be ready until it's serving on its socket.",
+			path:   PathReadyz,
+			want:   want{status: http.StatusServiceUnavailable},
+		},
+		"SinkCannotWrite": {
+			reason:  "The sidecar shouldn't be ready if a sink can't write events.",
+			path:    PathReadyz,
+			serving: true,
+			check:   errors.New("disk full"),
+			want:    want{status: http.StatusServiceUnavailable},
+		},
+		"Ready": {
+			reason:  "The sidecar should be ready when it's serving and its sinks can write.",
+			path:    PathReadyz,
+			serving: true,
+			want:    want{status: http.StatusOK},
+		},
+		"NotFound": {
+			reason: "Unknown paths should return 404.",
+			path:   "/nope",
+			want:   want{status: http.StatusNotFound},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			h := NewHealth(NewFanOutSink(checkFunc(func() error { return tc.check })))
+			if tc.serving {
+				h.SetServing(true)
+			}
+
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tc.path, nil))
+
+			if w.Code != tc.want.status {
+				t.Errorf("\n%s\nServeHTTP(%s): want status %d, got %d: %s", tc.reason, tc.path, tc.want.status, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestHealthGRPC(t *testing.T) {
+	var sinkErr error
+	h := NewHealth(checkFunc(func() error { return sinkErr }))
+
+	srv := grpc.NewServer()
+	h.Register(srv)
+	l, err := net.Listen("unix", filepath.Join(t.TempDir(), "socket"))
+	if err != nil {
+		t.Fatalf("Listen(...): %v", err)
+	}
+	go func() { _ = srv.Serve(l) }()
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("unix://"+l.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("NewClient(...): %v", err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	client := healthpb.NewHealthClient(conn)
+
+	status := func() healthpb.HealthCheckResponse_ServingStatus {
+		t.Helper()
+		rsp, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{
+			Service: pipelinev1alpha1.PipelineInspectorService_ServiceDesc.ServiceName,
+		})
+		if err != nil {
+			t.Fatalf("Check(...): %v", err)
+		}
+		return rsp.GetStatus()
+	}
+
+	if got := status(); got != healthpb.HealthCheckResponse_NOT_SERVING {
+		t.Errorf("before serving: want NOT_SERVING, got %s", got)
+	}
+
+	h.SetServing(true)
+	if got := status(); got != healthpb.HealthCheckResponse_SERVING {
+		t.Errorf("serving: want SERVING, got %s", got)
+	}
+
+	sinkErr = errors.New("disk full")
+	_ = h.Check()
+	if got := status(); got != healthpb.HealthCheckResponse_NOT_SERVING {
+		t.Errorf("sink cannot write: want NOT_SERVING, got %s", got)
+	}
+
+	sinkErr = nil
+	h.SetServing(false)
+	h.SetServing(true)
+	if got := status(); got != healthpb.HealthCheckResponse_NOT_SERVING {
+		t.Errorf("after shutdown: want NOT_SERVING, got %s", got)
+	}
+}
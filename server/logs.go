@@ -0,0 +1,160 @@
+/*
+Copyright 2026 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+this file except in compliance with the License. You may obtain a copy of the
+License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed
+under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+CONDITIONS OF ANY KIND, either express or implied. See the License for the
+specific language governing permissions and limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"time"
+
+	"go.opentelemetry.io/otel/log"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// LogLoggerName is the name of the logger that emits log records for events.
+const LogLoggerName = "github.com/crossplane/inspector-sidecar/server"
+
+// A LogSink exports each event as an OpenTelemetry log record. The record's
+// body is the event's payload, or its error if it has no payload, and its
+// attributes describe the function call.
+// Its trace and span IDs are those Crossplane recorded for the call, so
+// backends can link the record to the trace.
+//
+// A record's severity is ERROR if the function returned an error or a fatal
+// result, and INFO otherwise. Records are exported in batches.
+type LogSink struct {
+	lp     *sdklog.LoggerProvider
+	logger log.Logger
+}
+
+// NewLogSink returns a sink that exports log records using the supplied
+// exporter.
+func NewLogSink(exp sdklog.Exporter) *LogSink {
+	lp := sdklog.NewLoggerProvider(
+		sdklog.WithProcessor(sdklog.NewBatchProcessor(exp)),
+		sdklog.WithResource(otlpResource()),
+	)
+	return &LogSink{lp: lp, logger: lp.Logger(LogLoggerName)}
+}
+
+// Write a log record for the supplied event.
+func (s *LogSink) Write(e *Event) error {
+	payload, err := jsonValue(e.Payload)
+	if err != nil {
+		return fmt.Errorf("cannot convert payload: %w", err)
+	}
+
+	ctx := context.Background()
+	if sc := parentSpanContext(e); sc.IsValid() {
+		ctx = trace.ContextWithSpanContext(ctx, sc)
+	}
+
+	r := log.Record{}
+	r.SetObservedTimestamp(time.Now())
+	if ts := e.Meta.GetTimestamp(); ts != nil {
+		r.SetTimestamp(ts.AsTime())
+	}
+	switch {
+	case payload != nil:
+		r.SetBody(logValue(payload))
+	case e.Error != "":
+		// Events for failed calls have no payload, so the error is the body.
+		r.SetBody(log.StringValue(e.Error))
+	}
+
+	r.AddAttributes(log.String(AttrEventType, e.Type))
+	for _, kv := range stepMetaAttributes(e.Meta) {
+		r.AddAttributes(log.KeyValueFromAttribute(kv))
+	}
+	if step, ok := e.Payload.(*Step); ok && step.Incomplete {
+		r.AddAttributes(log.Bool(AttrStepIncomplete, true))
+	}
+
+	r.SetSeverity(log.SeverityInfo)
+	r.SetSeverityText("INFO")
+	if e.Error != "" {
+		r.AddAttributes(log.String(AttrFunctionError, e.Error))
+	}
+	if _, fatal := eventFatalResult(e); fatal || e.Error != "" {
+		r.SetSeverity(log.SeverityError)
+		r.SetSeverityText("ERROR")
+	}
+
+	s.logger.Emit(ctx, r)
+	return nil
+}
+
+// Sync exports all log records that haven't been exported yet.
+func (s *LogSink) Sync() error {
+	return s.lp.ForceFlush(context.Background())
+}
+
+// Close exports all log records that haven't been exported yet, then shuts
+// down the exporter. Records that can't be exported within
+// otlpShutdownTimeout are dropped.
+func (s *LogSink) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), otlpShutdownTimeout)
+	defer cancel()
+	return s.lp.Shutdown(ctx)
+}
+
+// eventFatalResult returns the message of the first fatal result of the
+// response in the supplied RESPONSE or STEP event, and true if it has one.
+func eventFatalResult(e *Event) (string, bool) {
+	switch e.Type {
+	case EventTypeResponse:
+		return fatalResult(e.Payload)
+	case EventTypeStep:
+		if step, ok := e.Payload.(*Step); ok {
+			return fatalResult(step.Response)
+		}
+	}
+	return "", false
+}
+
+// logValue converts the supplied generic JSON value to a log value. Whole
+// numbers become integers, and object keys are sorted.
+func logValue(v any) log.Value {
+	switch v := v.(type) {
+	case map[string]any:
+		kvs := make([]log.KeyValue, 0, len(v))
+		for _, k := range slices.Sorted(maps.Keys(v)) {
+			kvs = append(kvs, log.KeyValue{Key: k, Value: logValue(v[k])})
+		}
+		return log.MapValue(kvs...)
+	case []any:
+		vs := make([]log.Value, 0, len(v))
+		for _, e := range v {
+			vs = append(vs, logValue(e))
+		}
+		return log.SliceValue(vs...)
+	case string:
+		return log.StringValue(v)
+	case bool:
+		return log.BoolValue(v)
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) < 1<<53 {
+			return log.Int64Value(int64(v))
+		}
+		return log.Float64Value(v)
+	default:
+		return log.Value{}
+	}
+}
@@ -0,0 +1,211 @@
+/*
+Copyright 2026 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+this file except in compliance with the License. You may obtain a copy of the
+License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed
+under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+CONDITIONS OF ANY KIND, either express or implied. See the License for the
+specific language governing permissions and limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"encoding/hex"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	collectorlogsv1 "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	commonv1 "go.opentelemetry.io/proto/otlp/common/v1"
+	logsv1 "go.opentelemetry.io/proto/otlp/logs/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pipelinev1alpha1 "github.com/crossplane/crossplane-runtime/v2/apis/pipelineinspector/proto/v1alpha1"
+)
+
+// A logsCollector is an in-process OTLP logs collector.
+type logsCollector struct {
+	collectorlogsv1.UnimplementedLogsServiceServer
+
+	mu      sync.Mutex
+	records []*logsv1.LogRecord
+}
+
+func (c *logsCollector) Export(_ context.Context, req *collectorlogsv1.ExportLogsServiceRequest) (*collectorlogsv1.ExportLogsServiceResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, rl := range req.GetResourceLogs() {
+		for _, sl := range rl.GetScopeLogs() {
+			c.records = append(c.records, sl.GetLogRecords()...)
+		}
+	}
+	return &collectorlogsv1.ExportLogsServiceResponse{}, nil
+}
+
+func TestLogSink(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen(...): %v", err)
+	}
+	collector := &logsCollector{}
+	srv := grpc.NewServer()
+	collectorlogsv1.RegisterLogsServiceServer(srv, collector)
+	go func() { _ = srv.Serve(l) }()
+	t.Cleanup(srv.Stop)
+
+	exp, err := NewOTLPLogExporter(context.Background(), OTLPEndpoint{Endpoint: l.Addr().String(), Insecure: true})
+	if err != nil {
+		t.Fatalf("NewOTLPLogExporter(...): %v", err)
+	}
+	s := NewLogSink(exp)
+
+	ts := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	meta := &pipelinev1alpha1.StepMeta{
+		TraceId:      "0102030405060708090a0b0c0d0e0f10",
+		SpanId:       "0102030405060708",
+		StepName:     "patch",
+		FunctionName: "function-patch",
+		Timestamp:    timestamppb.New(ts),
+		Context: &pipelinev1alpha1.StepMeta_OperationMeta{OperationMeta: &pipelinev1alpha1.OperationMeta{
+			OperationName: "my-op",
+			OperationUid:  "uid-1",
+		}},
+	}
+	events := []*Event{
+		{Type: EventTypeRequest, Meta: meta, Payload: map[string]any{"meta": map[string]any{"tag": "abc"}, "observed": map[string]any{}}},
+		{Type: EventTypeResponse, Meta: meta, Payload: map[string]any{
+			"results": []any{map[string]any{"severity": "SEVERITY_FATAL", "message": "invalid input"}},
+		}},
+		{Type: EventTypeResponse, Meta: &pipelinev1alpha1.StepMeta{FunctionName: "function-patch"}, Error: "connection refused"},
+	}
+	for _, e := range events {
+		if err := s.Write(e); err != nil {
+			t.Fatalf("Write(...): %v", err)
+		}
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close(): %v", err)
+	}
+
+	type record struct {
+		Time     time.Time
+		Severity string
+		TraceID  string
+		SpanID   string
+		Body     any
+		Attrs    map[string]string
+	}
+	collector.mu.Lock()
+	got := make([]record, 0, len(collector.records))
+	for _, r := range collector.records {
+		attrs := map[string]string{}
+		for _, kv := range r.GetAttributes() {
+			attrs[kv.GetKey()] = attrString(kv.GetValue())
+		}
+		got = append(got, record{
+			Time:     time.Unix(0, int64(r.GetTimeUnixNano())).UTC(),
+			Severity: r.GetSeverityText(),
+			TraceID:  hex.EncodeToString(r.GetTraceId()),
+			SpanID:   hex.EncodeToString(r.GetSpanId()),
+			Body:     anyValue(r.GetBody()),
+			Attrs:    attrs,
+		})
+	}
+	collector.mu.Unlock()
+
+	attrs := map[string]string{
+		AttrFunctionName:  "function-patch",
+		AttrStepName:      "patch",
+		AttrStepIndex:     "0",
+		AttrStepIteration: "0",
+		AttrOperationName: "my-op",
+		AttrOperationUID:  "uid-1",
+	}
+	with := func(kvs ...string) map[string]string {
+		m := map[string]string{}
+		for k, v := range attrs {
+			m[k] = v
+		}
+		for i := 0; i < len(kvs); i += 2 {
+			m[kvs[i]] = kvs[i+1]
+		}
+		return m
+	}
+	want := []record{
+		{
+			Time:     ts,
+			Severity: "INFO",
+			TraceID:  "0102030405060708090a0b0c0d0e0f10",
+			SpanID:   "0102030405060708",
+			Body:     map[string]any{"meta": map[string]any{"tag": "abc"}, "observed": map[string]any{}},
+			Attrs:    with(AttrEventType, EventTypeRequest),
+		},
+		{
+			Time:     ts,
+			Severity: "ERROR",
+			TraceID:  "0102030405060708090a0b0c0d0e0f10",
+			SpanID:   "0102030405060708",
+			Body: map[string]any{
+				"results": []any{map[string]any{"severity": "SEVERITY_FATAL", "message": "invalid input"}},
+			},
+			Attrs: with(AttrEventType, EventTypeResponse),
+		},
+		{
+			Time:     time.Unix(0, 0).UTC(),
+			Severity: "ERROR",
+			Body:     "connection refused",
+			Attrs: map[string]string{
+				AttrEventType:     EventTypeResponse,
+				AttrFunctionName:  "function-patch",
+				AttrFunctionError: "connection refused",
+				AttrStepIndex:     "0",
+				AttrStepIteration: "0",
+			},
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("exported log records mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestLogValue(t *testing.T) {
+	got := logValue(map[string]any{
+		"b": []any{1.0, 1.5, true, nil},
+		"a": "x",
+	})
+	want := "[a:x b:[1 1.5 true <nil>]]"
+	if diff := cmp.Diff(want, got.String()); diff != "" {
+		t.Errorf("logValue(...) mismatch (-want +got):\n%s", diff)
+	}
+}
+
+// anyValue converts the supplied OTLP value to generic JSON values.
+func anyValue(v *commonv1.AnyValue) any {
+	switch x := v.GetValue().(type) {
+	case *commonv1.AnyValue_KvlistValue:
+		m := map[string]any{}
+		for _, kv := range x.KvlistValue.GetValues() {
+			m[kv.GetKey()] = anyValue(kv.GetValue())
+		}
+		return m
+	case *commonv1.AnyValue_ArrayValue:
+		s := []any{}
+		for _, e := range x.ArrayValue.GetValues() {
+			s = append(s, anyValue(e))
+		}
+		return s
+	case *commonv1.AnyValue_StringValue:
+		return x.StringValue
+	}
+	return nil
+}
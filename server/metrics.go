@@ -0,0 +1,225 @@
+/*
+Copyright 2026 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+this file except in compliance with the License. You may obtain a copy of the
+License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed
+under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+CONDITIONS OF ANY KIND, either express or implied. See the License for the
+specific language governing permissions and limitations under the License.
+*/
+
+package server
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	pipelinev1alpha1 "github.com/crossplane/crossplane-runtime/v2/apis/pipelineinspector/proto/v1alpha1"
+)
+
+// MetricsNamespace prefixes the names of all metrics.
+const MetricsNamespace = "pipeline_inspector"
+
+// Metric labels.
+const (
+	labelType        = "type"
+	labelFunction    = "function"
+	labelComposition = "composition"
+	labelStep        = "step"
+	labelSink        = "sink"
+)
+
+// A MetricsOption configures Metrics.
+type MetricsOption func(*Metrics)
+
+// WithPendingTimeout sets how long Metrics waits for a request's response
+// before it stops tracking the request (default: 10m).
+func WithPendingTimeout(d time.Duration) MetricsOption {
+	return func(m *Metrics) {
+		m.timeout = d
+	}
+}
+
+// Metrics records Prometheus metrics about the function calls an Inspector
+// captures, and about the sinks it writes them to. Metrics implements
+// prometheus.Collector.
+//
+// Step durations are measured between the timestamps of a function's request
+// and response, independently of whether steps are paired into STEP events.
+type Metrics struct {
+	events       *prometheus.CounterVec
+	errors       *prometheus.CounterVec
+	duration     *prometheus.HistogramVec
+	payloadBytes *prometheus.HistogramVec
+	sinkErrors   *prometheus.CounterVec
+
+	timeout time.Duration
+	now     func() time.Time
+
+	mu        sync.Mutex
+	pending   map[stepKey]pendingTimestamp
+	lastPrune time.Time
+}
+
+type pendingTimestamp struct {
+	sent     time.Time
+	received time.Time
+}
+
+// NewMetrics returns new, unregistered Metrics.
+func NewMetrics(opts ...MetricsOption) *Metrics {
+	m := &Metrics{
+		events: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: MetricsNamespace,
+			Name:      "events_total",
+			Help:      "Number of REQUEST and RESPONSE events received, by function, composition and step.",
+		}, []string{labelType, labelFunction, labelComposition, labelStep}),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: MetricsNamespace,
+			Name:      "function_errors_total",
+			Help:      "Number of function calls that returned an error, by function, composition and step.",
+		}, []string{labelFunction, labelComposition, labelStep}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: MetricsNamespace,
+			Name:      "step_duration_seconds",
+			Help:      "Time between a function's request and response, by function, composition and step.",
+			Buckets:   prometheus.ExponentialBuckets(0.005, 2, 14),
+		}, []string{labelFunction, labelComposition, labelStep}),
+		payloadBytes: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: MetricsNamespace,
+			Name:      "payload_bytes",
+			Help:      "Size of request and response payloads in bytes, by event type and function.",
+			Buckets:   prometheus.ExponentialBuckets(1024, 4, 10),
+		}, []string{labelType, labelFunction}),
+		sinkErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: MetricsNamespace,
+			Name:      "sink_write_errors_total",
+			Help:      "Number of events a sink failed to write, by sink kind.",
+		}, []string{labelSink}),
+		timeout: 10 * time.Minute,
+		now:     time.Now,
+		pending: make(map[stepKey]pendingTimestamp),
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// Describe sends the descriptors of all metrics to the supplied channel.
+func (m *Metrics) Describe(ch chan<- *prometheus.Desc) {
+	m.events.Describe(ch)
+	m.errors.Describe(ch)
+	m.duration.Describe(ch)
+	m.payloadBytes.Describe(ch)
+	m.sinkErrors.Describe(ch)
+}
+
+// Collect sends all metrics to the supplied channel.
+func (m *Metrics) Collect(ch chan<- prometheus.Metric) {
+	m.events.Collect(ch)
+	m.errors.Collect(ch)
+	m.duration.Collect(ch)
+	m.payloadBytes.Collect(ch)
+	m.sinkErrors.Collect(ch)
+}
+
+// Observe records a REQUEST or RESPONSE event with the supplied metadata,
+// payload size and error.
+func (m *Metrics) Observe(eventType string, meta *pipelinev1alpha1.StepMeta, size int, errMsg string) {
+	fn := meta.GetFunctionName()
+	comp := meta.GetCompositionMeta().GetCompositionName()
+	step := meta.GetStepName()
+
+	m.events.WithLabelValues(eventType, fn, comp, step).Inc()
+	m.payloadBytes.WithLabelValues(eventType, fn).Observe(float64(size))
+	if errMsg != "" {
+		m.errors.WithLabelValues(fn, comp, step).Inc()
+	}
+
+	ts := meta.GetTimestamp()
+	if ts == nil {
+		return
+	}
+	k := stepKeyFor(meta)
+	now := m.now()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.prune(now)
+	switch eventType {
+	case EventTypeRequest:
+		m.pending[k] = pendingTimestamp{sent: ts.AsTime(), received: now}
+	case EventTypeResponse:
+		req, ok := m.pending[k]
+		if !ok {
+			return
+		}
+		delete(m.pending, k)
+		m.duration.WithLabelValues(fn, comp, step).Observe(ts.AsTime().Sub(req.sent).Seconds())
+	}
+}
+
+// prune forgets requests whose response hasn't arrived within the pending
+// timeout. It runs at most once per timeout. It must be called with the lock
+// held.
+func (m *Metrics) prune(now time.Time) {
+	if now.Sub(m.lastPrune) < m.timeout {
+		return
+	}
+	m.lastPrune = now
+	for k, p := range m.pending {
+		if now.Sub(p.received) >= m.timeout {
+			delete(m.pending, k)
+		}
+	}
+}
+
+// InstrumentSink returns a sink that counts the supplied sink's write errors,
+// labelled with the supplied kind. It returns the supplied sink unchanged if
+// the Metrics are nil.
+func (m *Metrics) InstrumentSink(kind string, s Sink) Sink {
+	if m == nil {
+		return s
+	}
+	return &instrumentedSink{wrappedSink: wrappedSink{sink: s}, errors: m.sinkErrors.WithLabelValues(kind)}
+}
+
+type instrumentedSink struct {
+	wrappedSink
+
+	errors prometheus.Counter
+}
+
+func (s *instrumentedSink) Write(e *Event) error {
+	err := s.sink.Write(e)
+	if err != nil {
+		s.errors.Inc()
+	}
+	return err
+}
+
+// NewQueueCollectors returns collectors that report the depth of the supplied
+// queue, and the number of events it dropped.
+func NewQueueCollectors(q *QueueSink) []prometheus.Collector {
+	return []prometheus.Collector{
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: MetricsNamespace,
+			Name:      "queue_depth",
+			Help:      "Number of events waiting in the queue to be written.",
+		}, func() float64 { return float64(q.Len()) }),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace: MetricsNamespace,
+			Name:      "events_dropped_total",
+			Help:      "Number of events dropped because the queue was full.",
+		}, func() float64 { return float64(q.Dropped()) }),
+	}
+}
@@ -0,0 +1,176 @@
+/*
+Copyright 2026 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+this file except in compliance with the License. You may obtain a copy of the
+License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed
+under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+CONDITIONS OF ANY KIND, either express or implied. See the License for the
+specific language governing permissions and limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pipelinev1alpha1 "github.com/crossplane/crossplane-runtime/v2/apis/pipelineinspector/proto/v1alpha1"
+)
+
+func TestMetricsObserve(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	meta := func(span string, ts time.Time) *pipelinev1alpha1.StepMeta {
+		return &pipelinev1alpha1.StepMeta{
+			TraceId:      "trace",
+			SpanId:       span,
+			FunctionName: "function-patch",
+			StepName:     "patch",
+			Timestamp:    timestamppb.New(ts),
+			Context: &pipelinev1alpha1.StepMeta_CompositionMeta{CompositionMeta: &pipelinev1alpha1.CompositionMeta{
+				CompositeResourceUid: "uid",
+				CompositionName:      "my-composition",
+			}},
+		}
+	}
+
+	m := NewMetrics()
+	m.Observe(EventTypeRequest, meta("span-1", start), 100, "")
+	m.Observe(EventTypeResponse, meta("span-1", start.Add(1500*time.Millisecond)), 2000, "")
+	m.Observe(EventTypeRequest, meta("span-2", start), 100, "")
+	m.Observe(EventTypeResponse, meta("span-2", start.Add(500*time.Millisecond)), 0, "boom")
+
+	// A response without a request has no duration.
+	m.Observe(EventTypeResponse, meta("span-3", start), 0, "")
+
+	want := `
+# HELP pipeline_inspector_events_total Number of REQUEST and RESPONSE events received, by function, composition and step.
+# TYPE pipeline_inspector_events_total counter
+pipeline_inspector_events_total{composition="my-composition",function="function-patch",step="patch",type="REQUEST"} 2
+pipeline_inspector_events_total{composition="my-composition",function="function-patch",step="patch",type="RESPONSE"} 3
+# HELP pipeline_inspector_function_errors_total Number of function calls that returned an error, by function, composition and step.
+# TYPE pipeline_inspector_function_errors_total counter
+pipeline_inspector_function_errors_total{composition="my-composition",function="function-patch",step="patch"} 1
+`
+	if err := testutil.CollectAndCompare(m, strings.NewReader(want), "pipeline_inspector_events_total", "pipeline_inspector_function_errors_total"); err != nil {
+		t.Errorf("CollectAndCompare(...): %v", err)
+	}
+
+	h, ok := m.duration.WithLabelValues("function-patch", "my-composition", "patch").(prometheus.Histogram)
+	if !ok {
+		t.Fatal("step duration is not a histogram")
+	}
+	if got, want := histogramSum(t, h), 2.0; got != want {
+		t.Errorf("step duration sum: want %v, got %v", want, got)
+	}
+	if got, want := len(m.pending), 0; got != want {
+		t.Errorf("pending requests: want %d, got %d", want, got)
+	}
+}
+
+func TestMetricsPrune(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	m := NewMetrics(WithPendingTimeout(time.Minute))
+	m.now = func() time.Time { return now }
+
+	req := &pipelinev1alpha1.StepMeta{SpanId: "span-1", Timestamp: timestamppb.New(now)}
+	m.Observe(EventTypeRequest, req, 0, "")
+
+	now = now.Add(2 * time.Minute)
+	m.Observe(EventTypeRequest, &pipelinev1alpha1.StepMeta{SpanId: "span-2", Timestamp: timestamppb.New(now)}, 0, "")
+
+	if got, want := len(m.pending), 1; got != want {
+		t.Errorf("pending requests: want %d, got %d", want, got)
+	}
+}
+
+func TestMetricsInstrumentSink(t *testing.T) {
+	m := NewMetrics()
+	fail := m.InstrumentSink("file", SinkFunc(func(*Event) error { return errors.New("boom") }))
+	ok := m.InstrumentSink("stdout", SinkFunc(func(*Event) error { return nil }))
+
+	for range 2 {
+		_ = fail.Write(&Event{})
+		_ = ok.Write(&Event{})
+	}
+
+	want := `
+# HELP pipeline_inspector_sink_write_errors_total Number of events a sink failed to write, by sink kind.
+# TYPE pipeline_inspector_sink_write_errors_total counter
+pipeline_inspector_sink_write_errors_total{sink="file"} 2
+pipeline_inspector_sink_write_errors_total{sink="stdout"} 0
+`
+	if err := testutil.CollectAndCompare(m, strings.NewReader(want), "pipeline_inspector_sink_write_errors_total"); err != nil {
+		t.Errorf("CollectAndCompare(...): %v", err)
+	}
+
+	var nilMetrics *Metrics
+	s := SinkFunc(func(*Event) error { return nil })
+	if got := nilMetrics.InstrumentSink("file", s); got == nil {
+		t.Error("InstrumentSink on nil Metrics: want the supplied sink, got nil")
+	}
+}
+
+func TestQueueCollectors(t *testing.T) {
+	block := make(chan struct{})
+	q := NewQueueSink(SinkFunc(func(*Event) error {
+		<-block
+		return nil
+	}), WithQueueSize(1))
+
+	// The worker blocks on the first event, the second fills the queue and
+	// the third is dropped.
+	_ = q.Write(&Event{})
+	deadline := time.Now().Add(5 * time.Second)
+	for q.Len() != 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("worker never took the first event")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	_ = q.Write(&Event{})
+	_ = q.Write(&Event{})
+
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(NewQueueCollectors(q)...)
+
+	want := `
+# HELP pipeline_inspector_events_dropped_total Number of events dropped because the queue was full.
+# TYPE pipeline_inspector_events_dropped_total counter
+pipeline_inspector_events_dropped_total 1
+# HELP pipeline_inspector_queue_depth Number of events waiting in the queue to be written.
+# TYPE pipeline_inspector_queue_depth gauge
+pipeline_inspector_queue_depth 1
+`
+	if err := testutil.GatherAndCompare(reg, strings.NewReader(want)); err != nil {
+		t.Errorf("GatherAndCompare(...): %v", err)
+	}
+
+	close(block)
+	if err := q.Drain(context.Background()); err != nil {
+		t.Errorf("Drain(...): %v", err)
+	}
+}
+
+func histogramSum(t *testing.T, h prometheus.Histogram) float64 {
+	t.Helper()
+	ch := make(chan prometheus.Metric, 1)
+	h.Collect(ch)
+	m := &dto.Metric{}
+	if err := (<-ch).Write(m); err != nil {
+		t.Fatalf("Write(...): %v", err)
+	}
+	return m.GetHistogram().GetSampleSum()
+}
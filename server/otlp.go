@@ -0,0 +1,223 @@
+/*
+Copyright 2026 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+this file except in compliance with the License. You may obtain a copy of the
+License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed
+under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+CONDITIONS OF ANY KIND, either express or implied. See the License for the
+specific language governing permissions and limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlplog/otlploggrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlplog/otlploghttp"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+
+	pipelinev1alpha1 "github.com/crossplane/crossplane-runtime/v2/apis/pipelineinspector/proto/v1alpha1"
+)
+
+// OTLP protocols.
+const (
+	OTLPProtocolGRPC = "grpc"
+	OTLPProtocolHTTP = "http/protobuf"
+)
+
+// OTLPServiceName is the service.name resource attribute of exported
+// telemetry.
+const OTLPServiceName = "crossplane-pipeline-inspector"
+
+// Exports that fail with a retryable error are retried with exponential
+// backoff, until they have been retried for otlpRetryMaxElapsed.
+const (
+	otlpRetryInitialInterval = time.Second
+	otlpRetryMaxInterval     = 30 * time.Second
+	otlpRetryMaxElapsed      = 5 * time.Minute
+)
+
+// Sinks give up exporting buffered telemetry when they're closed if it takes
+// longer than otlpShutdownTimeout, so a slow or unreachable collector can't
+// block shutdown or a config reload.
+const otlpShutdownTimeout = 10 * time.Second
+
+// Attributes describing a function call, derived from its StepMeta.
+const (
+	AttrEventType       = "crossplane.event.type"
+	AttrFunctionError   = "crossplane.function.error"
+	AttrFunctionName    = "crossplane.function.name"
+	AttrStepName        = "crossplane.step.name"
+	AttrStepIndex       = "crossplane.step.index"
+	AttrStepIteration   = "crossplane.step.iteration"
+	AttrStepIncomplete  = "crossplane.step.incomplete"
+	AttrXRAPIVersion    = "crossplane.xr.api_version"
+	AttrXRKind          = "crossplane.xr.kind"
+	AttrXRName          = "crossplane.xr.name"
+	AttrXRNamespace     = "crossplane.xr.namespace"
+	AttrXRUID           = "crossplane.xr.uid"
+	AttrCompositionName = "crossplane.composition.name"
+	AttrOperationName   = "crossplane.operation.name"
+	AttrOperationUID    = "crossplane.operation.uid"
+)
+
+// An OTLPEndpoint configures where OTLP telemetry is sent.
+type OTLPEndpoint struct {
+	// Protocol is OTLPProtocolGRPC (the default) or OTLPProtocolHTTP.
+	Protocol string
+
+	// Endpoint is a host and port such as otel-collector:4317, or a URL such
+	// as https://otel-collector:4318/v1/traces. If empty, the standard
+	// OTEL_EXPORTER_OTLP_* environment variables are used.
+	Endpoint string
+
+	// Insecure disables TLS.
+	Insecure bool
+}
+
+// Validate returns an error if the endpoint's protocol is unknown.
+func (e OTLPEndpoint) Validate() error {
+	switch e.Protocol {
+	case "", OTLPProtocolGRPC, OTLPProtocolHTTP:
+		return nil
+	default:
+		return fmt.Errorf("unknown OTLP protocol %q: must be %s or %s", e.Protocol, OTLPProtocolGRPC, OTLPProtocolHTTP)
+	}
+}
+
+// NewOTLPSpanExporter returns an exporter that sends spans to the supplied
+// endpoint. Failed exports are retried with exponential backoff. It doesn't
+// connect until spans are exported.
+func NewOTLPSpanExporter(ctx context.Context, e OTLPEndpoint) (sdktrace.SpanExporter, error) {
+	if err := e.Validate(); err != nil {
+		return nil, err
+	}
+	isURL := strings.Contains(e.Endpoint, "://")
+
+	if e.Protocol == OTLPProtocolHTTP {
+		opts := []otlptracehttp.Option{otlptracehttp.WithRetry(otlptracehttp.RetryConfig{
+			Enabled:         true,
+			InitialInterval: otlpRetryInitialInterval,
+			MaxInterval:     otlpRetryMaxInterval,
+			MaxElapsedTime:  otlpRetryMaxElapsed,
+		})}
+		switch {
+		case isURL:
+			opts = append(opts, otlptracehttp.WithEndpointURL(e.Endpoint))
+		case e.Endpoint != "":
+			opts = append(opts, otlptracehttp.WithEndpoint(e.Endpoint))
+		}
+		if e.Insecure {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		return otlptracehttp.New(ctx, opts...)
+	}
+
+	opts := []otlptracegrpc.Option{otlptracegrpc.WithRetry(otlptracegrpc.RetryConfig{
+		Enabled:         true,
+		InitialInterval: otlpRetryInitialInterval,
+		MaxInterval:     otlpRetryMaxInterval,
+		MaxElapsedTime:  otlpRetryMaxElapsed,
+	})}
+	switch {
+	case isURL:
+		opts = append(opts, otlptracegrpc.WithEndpointURL(e.Endpoint))
+	case e.Endpoint != "":
+		opts = append(opts, otlptracegrpc.WithEndpoint(e.Endpoint))
+	}
+	if e.Insecure {
+		opts = append(opts, otlptracegrpc.WithInsecure())
+	}
+	return otlptracegrpc.New(ctx, opts...)
+}
+
+// NewOTLPLogExporter returns an exporter that sends log records to the
+// supplied endpoint. Failed exports are retried with exponential backoff. It
+// doesn't connect until log records are exported.
+func NewOTLPLogExporter(ctx context.Context, e OTLPEndpoint) (sdklog.Exporter, error) {
+	if err := e.Validate(); err != nil {
+		return nil, err
+	}
+	isURL := strings.Contains(e.Endpoint, "://")
+
+	if e.Protocol == OTLPProtocolHTTP {
+		opts := []otlploghttp.Option{otlploghttp.WithRetry(otlploghttp.RetryConfig{
+			Enabled:         true,
+			InitialInterval: otlpRetryInitialInterval,
+			MaxInterval:     otlpRetryMaxInterval,
+			MaxElapsedTime:  otlpRetryMaxElapsed,
+		})}
+		switch {
+		case isURL:
+			opts = append(opts, otlploghttp.WithEndpointURL(e.Endpoint))
+		case e.Endpoint != "":
+			opts = append(opts, otlploghttp.WithEndpoint(e.Endpoint))
+		}
+		if e.Insecure {
+			opts = append(opts, otlploghttp.WithInsecure())
+		}
+		return otlploghttp.New(ctx, opts...)
+	}
+
+	opts := []otlploggrpc.Option{otlploggrpc.WithRetry(otlploggrpc.RetryConfig{
+		Enabled:         true,
+		InitialInterval: otlpRetryInitialInterval,
+		MaxInterval:     otlpRetryMaxInterval,
+		MaxElapsedTime:  otlpRetryMaxElapsed,
+	})}
+	switch {
+	case isURL:
+		opts = append(opts, otlploggrpc.WithEndpointURL(e.Endpoint))
+	case e.Endpoint != "":
+		opts = append(opts, otlploggrpc.WithEndpoint(e.Endpoint))
+	}
+	if e.Insecure {
+		opts = append(opts, otlploggrpc.WithInsecure())
+	}
+	return otlploggrpc.New(ctx, opts...)
+}
+
+// otlpResource returns the resource describing the inspector.
+func otlpResource() *resource.Resource {
+	return resource.NewSchemaless(attribute.String("service.name", OTLPServiceName))
+}
+
+// stepMetaAttributes returns attributes describing the function call with the
+// supplied metadata. Empty values are omitted.
+func stepMetaAttributes(meta *pipelinev1alpha1.StepMeta) []attribute.KeyValue {
+	attrs := []attribute.KeyValue{
+		attribute.Int(AttrStepIndex, int(meta.GetStepIndex())),
+		attribute.Int(AttrStepIteration, int(meta.GetIteration())),
+	}
+	for _, kv := range []attribute.KeyValue{
+		attribute.String(AttrFunctionName, meta.GetFunctionName()),
+		attribute.String(AttrStepName, meta.GetStepName()),
+		attribute.String(AttrXRAPIVersion, meta.GetCompositionMeta().GetCompositeResourceApiVersion()),
+		attribute.String(AttrXRKind, meta.GetCompositionMeta().GetCompositeResourceKind()),
+		attribute.String(AttrXRName, meta.GetCompositionMeta().GetCompositeResourceName()),
+		attribute.String(AttrXRNamespace, meta.GetCompositionMeta().GetCompositeResourceNamespace()),
+		attribute.String(AttrXRUID, meta.GetCompositionMeta().GetCompositeResourceUid()),
+		attribute.String(AttrCompositionName, meta.GetCompositionMeta().GetCompositionName()),
+		attribute.String(AttrOperationName, meta.GetOperationMeta().GetOperationName()),
+		attribute.String(AttrOperationUID, meta.GetOperationMeta().GetOperationUid()),
+	} {
+		if kv.Value.AsString() != "" {
+			attrs = append(attrs, kv)
+		}
+	}
+	return attrs
+}
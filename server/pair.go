@@ -0,0 +1,285 @@
+/*
+Copyright 2026 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+this file except in compliance with the License. You may obtain a copy of the
+License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed
+under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+CONDITIONS OF ANY KIND, either express or implied. See the License for the
+specific language governing permissions and limitations under the License.
+*/
+
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	pipelinev1alpha1 "github.com/crossplane/crossplane-runtime/v2/apis/pipelineinspector/proto/v1alpha1"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/logging"
+)
+
+// EventTypeStep is the type of events that pair a function's request and
+// response.
+const EventTypeStep = "STEP"
+
+// A Step is the payload of a STEP event. It pairs the request sent to a
+// function with the response it returned.
+type Step struct {
+	// Request is the decoded RunFunctionRequest.
+	Request any
+
+	// Response is the decoded RunFunctionResponse.
+	Response any
+
+	// Duration between the request and response timestamps.
+	Duration time.Duration
+
+	// Incomplete is true if the request or the response wasn't seen, for
+	// example because no response arrived before the pairing timeout.
+	Incomplete bool
+
+	// Diff describes how the function changed the desired state, if it was
+	// computed by a StepDiffSink.
+	Diff *StepDiff
+}
+
+// MarshalJSON marshals the step, rendering its duration as a string such as
+// "1.5s" and omitting empty fields.
+func (s *Step) MarshalJSON() ([]byte, error) {
+	step := map[string]any{
+		"duration": s.Duration.String(),
+	}
+	if s.Request != nil {
+		step["request"] = s.Request
+	}
+	if s.Response != nil {
+		step["response"] = s.Response
+	}
+	if s.Incomplete {
+		step["incomplete"] = true
+	}
+	if s.Diff != nil {
+		step["diff"] = s.Diff.Patch
+	}
+	return json.Marshal(step)
+}
+
+// A PairingOption configures a PairingSink.
+type PairingOption func(*PairingSink)
+
+// WithStepTimeout sets how long a request waits for its response before it is
+// written as an incomplete step (default: 1m).
+func WithStepTimeout(d time.Duration) PairingOption {
+	return func(s *PairingSink) {
+		s.timeout = d
+	}
+}
+
+// WithPairingLogger sets the logger used to report sink errors.
+func WithPairingLogger(l logging.Logger) PairingOption {
+	return func(s *PairingSink) {
+		s.log = l
+	}
+}
+
+// stepKey identifies a single function call.
+type stepKey struct {
+	traceID    string
+	spanID     string
+	contextUID string
+	stepIndex  int32
+	iteration  int32
+}
+
+func stepKeyFor(meta *pipelinev1alpha1.StepMeta) stepKey {
+	return stepKey{
+		traceID:    meta.GetTraceId(),
+		spanID:     meta.GetSpanId(),
+		contextUID: contextUID(meta),
+		stepIndex:  meta.GetStepIndex(),
+		iteration:  meta.GetIteration(),
+	}
+}
+
+// contextUID returns the UID of the composite resource or operation that ran
+// the supplied step.
+func contextUID(meta *pipelinev1alpha1.StepMeta) string {
+	if uid := meta.GetCompositionMeta().GetCompositeResourceUid(); uid != "" {
+		return uid
+	}
+	return meta.GetOperationMeta().GetOperationUid()
+}
+
+type pendingRequest struct {
+	event    *Event
+	received time.Time
+}
+
+// A PairingSink holds each REQUEST event until the matching RESPONSE event
+// arrives, then writes a single STEP event to another sink. Requests and
+// responses are matched by trace ID, span ID, composite resource or operation
+// UID, step index and iteration. Other events are written unchanged.
+//
+// Pairing relies on a function's request being written before its response.
+// Don't write to a PairingSink from a QueueSink with more than one worker.
+type PairingSink struct {
+	wrappedSink
+
+	timeout time.Duration
+	log     logging.Logger
+	now     func() time.Time
+
+	mu      sync.Mutex
+	pending map[stepKey]pendingRequest
+
+	stop chan struct{}
+	done chan struct{}
+	once sync.Once
+}
+
+// NewPairingSink returns a sink that pairs requests with responses, writing
+// STEP events to the supplied sink. It starts a goroutine that writes requests
+// that time out waiting for a response as incomplete steps.
+func NewPairingSink(s Sink, opts ...PairingOption) *PairingSink {
+	p := &PairingSink{
+		wrappedSink: wrappedSink{sink: s},
+		timeout:     time.Minute,
+		log:         logging.NewNopLogger(),
+		now:         time.Now,
+		pending:     make(map[stepKey]pendingRequest),
+		stop:        make(chan struct{}),
+		done:        make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	go p.sweep()
+	return p
+}
+
+// Write the supplied event. Requests are held until their response arrives.
+func (p *PairingSink) Write(e *Event) error {
+	switch e.Type {
+	case EventTypeRequest:
+		k := stepKeyFor(e.Meta)
+		p.mu.Lock()
+		prev, ok := p.pending[k]
+		p.pending[k] = pendingRequest{event: e, received: p.now()}
+		p.mu.Unlock()
+
+		// A second request for the same call means we missed a response.
+		if ok {
+			return p.sink.Write(newStepEvent(prev.event, nil))
+		}
+		return nil
+
+	case EventTypeResponse:
+		k := stepKeyFor(e.Meta)
+		p.mu.Lock()
+		req, ok := p.pending[k]
+		delete(p.pending, k)
+		p.mu.Unlock()
+
+		if !ok {
+			return p.sink.Write(newStepEvent(nil, e))
+		}
+		return p.sink.Write(newStepEvent(req.event, e))
+	}
+
+	return p.sink.Write(e)
+}
+
+// Pending returns the number of requests waiting for a response.
+func (p *PairingSink) Pending() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.pending)
+}
+
+// Close writes all pending requests as incomplete steps, then closes the
+// underlying sink if it implements io.Closer.
+func (p *PairingSink) Close() error {
+	p.once.Do(func() { close(p.stop) })
+	<-p.done
+
+	p.mu.Lock()
+	pending := p.pending
+	p.pending = make(map[stepKey]pendingRequest)
+	p.mu.Unlock()
+
+	errs := make([]error, 0, len(pending)+1)
+	for _, req := range pending {
+		errs = append(errs, p.sink.Write(newStepEvent(req.event, nil)))
+	}
+	errs = append(errs, CloseSink(p.sink))
+	return errors.Join(errs...)
+}
+
+// sweep periodically writes requests that have waited longer than the timeout
+// as incomplete steps.
+func (p *PairingSink) sweep() {
+	defer close(p.done)
+
+	t := time.NewTicker(max(p.timeout/4, 10*time.Millisecond))
+	defer t.Stop()
+
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-t.C:
+			for _, e := range p.expired() {
+				if err := p.sink.Write(newStepEvent(e, nil)); err != nil {
+					p.log.Debug("Cannot write incomplete step", "error", err)
+				}
+			}
+		}
+	}
+}
+
+// expired removes and returns requests that have waited longer than the
+// timeout.
+func (p *PairingSink) expired() []*Event {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var expired []*Event
+	now := p.now()
+	for k, req := range p.pending {
+		if now.Sub(req.received) >= p.timeout {
+			expired = append(expired, req.event)
+			delete(p.pending, k)
+		}
+	}
+	return expired
+}
+
+// newStepEvent returns a STEP event pairing the supplied request and response.
+// Either may be nil, in which case the step is incomplete.
+func newStepEvent(req, rsp *Event) *Event {
+	step := &Step{Incomplete: req == nil || rsp == nil}
+	e := &Event{Type: EventTypeStep, Payload: step}
+
+	if req != nil {
+		step.Request = req.Payload
+		e.Meta = req.Meta
+	}
+	if rsp != nil {
+		step.Response = rsp.Payload
+		e.Error = rsp.Error
+		if e.Meta == nil {
+			e.Meta = rsp.Meta
+		}
+	}
+	if req != nil && rsp != nil && req.Meta.GetTimestamp() != nil && rsp.Meta.GetTimestamp() != nil {
+		step.Duration = rsp.Meta.GetTimestamp().AsTime().Sub(req.Meta.GetTimestamp().AsTime())
+	}
+	return e
+}
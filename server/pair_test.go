@@ -0,0 +1,164 @@
+/*
+Copyright 2026 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+this file except in compliance with the License. You may obtain a copy of the
+License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed
+under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+CONDITIONS OF ANY KIND, either express or implied. See the License for the
+specific language governing permissions and limitations under the License.
+*/
+
+package server
+
+import (
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/protobuf/testing/protocmp"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pipelinev1alpha1 "github.com/crossplane/crossplane-runtime/v2/apis/pipelineinspector/proto/v1alpha1"
+)
+
+// recordingSink records the events written to it.
+type recordingSink struct {
+	mu     sync.Mutex
+	events []*Event
+}
+
+func (s *recordingSink) Write(e *Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, e)
+	return nil
+}
+
+func (s *recordingSink) got() []*Event {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.events
+}
+
+func stepMeta(span string, step int32, ts time.Time) *pipelinev1alpha1.StepMeta {
+	return &pipelinev1alpha1.StepMeta{
+		TraceId:      "trace-1",
+		SpanId:       span,
+		StepIndex:    step,
+		FunctionName: "my-function",
+		Timestamp:    timestamppb.New(ts),
+		Context: &pipelinev1alpha1.StepMeta_CompositionMeta{
+			CompositionMeta: &pipelinev1alpha1.CompositionMeta{CompositeResourceUid: "uid-1"},
+		},
+	}
+}
+
+func TestPairingSink(t *testing.T) {
+	t0 := time.Date(2026, 1, 15, 10, 30, 0, 0, time.UTC)
+
+	reqA := &Event{Type: EventTypeRequest, Meta: stepMeta("span-a", 0, t0), Payload: "req-a"}
+	reqB := &Event{Type: EventTypeRequest, Meta: stepMeta("span-b", 1, t0.Add(time.Second)), Payload: "req-b"}
+	rspB := &Event{Type: EventTypeResponse, Meta: stepMeta("span-b", 1, t0.Add(1500*time.Millisecond)), Payload: "rsp-b", Error: "boom"}
+	rspA := &Event{Type: EventTypeResponse, Meta: stepMeta("span-a", 0, t0.Add(250*time.Millisecond)), Payload: "rsp-a"}
+	rspC := &Event{Type: EventTypeResponse, Meta: stepMeta("span-c", 2, t0), Payload: "rsp-c"}
+	other := &Event{Type: "OTHER"}
+
+	rec := &recordingSink{}
+	p := NewPairingSink(rec, WithStepTimeout(time.Hour))
+	for _, e := range []*Event{reqA, reqB, rspB, rspA, rspC, other} {
+		if err := p.Write(e); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+	}
+	if err := p.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	want := []*Event{
+		{Type: EventTypeStep, Meta: reqB.Meta, Error: "boom", Payload: &Step{Request: "req-b", Response: "rsp-b", Duration: 500 * time.Millisecond}},
+		{Type: EventTypeStep, Meta: reqA.Meta, Payload: &Step{Request: "req-a", Response: "rsp-a", Duration: 250 * time.Millisecond}},
+		{Type: EventTypeStep, Meta: rspC.Meta, Payload: &Step{Response: "rsp-c", Incomplete: true}},
+		other,
+	}
+	if diff := cmp.Diff(want, rec.got(), protocmp.Transform()); diff != "" {
+		t.Errorf("written events mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestPairingSink_Timeout(t *testing.T) {
+	rec := &recordingSink{}
+	p := NewPairingSink(rec, WithStepTimeout(20*time.Millisecond))
+	defer func() { _ = p.Close() }()
+
+	req := &Event{Type: EventTypeRequest, Meta: stepMeta("span-a", 0, time.Now()), Payload: "req-a"}
+	_ = p.Write(req)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for len(rec.got()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	want := []*Event{{Type: EventTypeStep, Meta: req.Meta, Payload: &Step{Request: "req-a", Incomplete: true}}}
+	if diff := cmp.Diff(want, rec.got(), protocmp.Transform()); diff != "" {
+		t.Errorf("written events mismatch (-want +got):\n%s", diff)
+	}
+	if got := p.Pending(); got != 0 {
+		t.Errorf("Pending(): want 0, got %d", got)
+	}
+}
+
+func TestPairingSink_Close(t *testing.T) {
+	rec := &recordingSink{}
+	p := NewPairingSink(rec, WithStepTimeout(time.Hour))
+
+	req := &Event{Type: EventTypeRequest, Meta: stepMeta("span-a", 0, time.Now()), Payload: "req-a"}
+	_ = p.Write(req)
+	if got := p.Pending(); got != 1 {
+		t.Errorf("Pending(): want 1, got %d", got)
+	}
+
+	// Orphaned requests are flushed as incomplete on close.
+	_ = p.Close()
+	want := []*Event{{Type: EventTypeStep, Meta: req.Meta, Payload: &Step{Request: "req-a", Incomplete: true}}}
+	if diff := cmp.Diff(want, rec.got(), protocmp.Transform()); diff != "" {
+		t.Errorf("written events mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestStepMarshalJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		step *Step
+		want string
+	}{
+		{
+			name: "complete",
+			step: &Step{Request: map[string]any{"a": 1}, Response: map[string]any{"b": 2}, Duration: 1500 * time.Millisecond},
+			want: `{"duration":"1.5s","request":{"a":1},"response":{"b":2}}`,
+		},
+		{
+			name: "incomplete",
+			step: &Step{Request: map[string]any{"a": 1}, Incomplete: true},
+			want: `{"duration":"0s","incomplete":true,"request":{"a":1}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := json.Marshal(tt.step)
+			if err != nil {
+				t.Fatalf("json.Marshal failed: %v", err)
+			}
+			if diff := cmp.Diff(tt.want, string(got)); diff != "" {
+				t.Errorf("MarshalJSON mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+/*
+Copyright 2026 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+this file except in compliance with the License. You may obtain a copy of the
+License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed
+under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+CONDITIONS OF ANY KIND, either express or implied. See the License for the
+specific language governing permissions and limitations under the License.
+*/
+
+package server
+
+import (
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+// Full names of the function protocol messages captured by the Inspector.
+const (
+	RunFunctionRequestName  protoreflect.FullName = "apiextensions.fn.proto.v1.RunFunctionRequest"
+	RunFunctionResponseName protoreflect.FullName = "apiextensions.fn.proto.v1.RunFunctionResponse"
+)
+
+// messageName returns the full name of the message carried by events of the
+// supplied type, or an empty name if they don't carry a known message.
+func messageName(eventType string) protoreflect.FullName {
+	switch eventType {
+	case EventTypeRequest:
+		return RunFunctionRequestName
+	case EventTypeResponse:
+		return RunFunctionResponseName
+	}
+	return ""
+}
+
+// decodeMessagePayload decodes the supplied protojson encoded payload of an
+// event of the supplied type.
+//
+// If the RunFunctionRequest or RunFunctionResponse message type can be
+// resolved, the payload is decoded into a typed message first, then decoded
+// from the message's canonical protojson encoding. This normalizes the payload
+// regardless of how the function's SDK encoded it - for example enums are
+// always names like SEVERITY_FATAL, durations are strings like 60s, and fields
+// always use their JSON names - so filters, redaction rules and summaries can
+// rely on the message's real field names and values.
+//
+// Payloads that don't match the type, for example because they were produced
+// by a newer version of the function protocol, fall back to generic JSON
+// decoding, then to the raw string. Nothing is dropped.
+func decodeMessagePayload(types protoregistry.MessageTypeResolver, eventType string, data []byte) any {
+	if len(data) == 0 || types == nil {
+		return decodeJSONPayload(data)
+	}
+	name := messageName(eventType)
+	if name == "" {
+		return decodeJSONPayload(data)
+	}
+	mt, err := types.FindMessageByName(name)
+	if err != nil {
+		return decodeJSONPayload(data)
+	}
+	m := mt.New().Interface()
+	if err := protojson.Unmarshal(data, m); err != nil {
+		return decodeJSONPayload(data)
+	}
+	canonical, err := protojson.Marshal(m)
+	if err != nil {
+		return decodeJSONPayload(data)
+	}
+	return decodeJSONPayload(canonical)
+}
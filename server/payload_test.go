@@ -0,0 +1,153 @@
+/*
+Copyright 2026 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+this file except in compliance with the License. You may obtain a copy of the
+License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed
+under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+CONDITIONS OF ANY KIND, either express or implied. See the License for the
+specific language governing permissions and limitations under the License.
+*/
+
+package server
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+	_ "google.golang.org/protobuf/types/known/durationpb" // Registers google/protobuf/duration.proto.
+)
+
+// responseTypes returns a registry with a cut down RunFunctionResponse type,
+// which has a meta with a tag and TTL, and results with a severity and message.
+func responseTypes(t *testing.T) *protoregistry.Types {
+	t.Helper()
+
+	field := func(name string, n int32, typ descriptorpb.FieldDescriptorProto_Type, typeName string, repeated bool) *descriptorpb.FieldDescriptorProto {
+		label := descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL
+		if repeated {
+			label = descriptorpb.FieldDescriptorProto_LABEL_REPEATED
+		}
+		f := &descriptorpb.FieldDescriptorProto{Name: proto.String(name), Number: proto.Int32(n), Type: typ.Enum(), Label: label.Enum()}
+		if typeName != "" {
+			f.TypeName = proto.String(typeName)
+		}
+		return f
+	}
+	msg := descriptorpb.FieldDescriptorProto_TYPE_MESSAGE
+
+	fd, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:       proto.String("run_function.proto"),
+		Package:    proto.String("apiextensions.fn.proto.v1"),
+		Syntax:     proto.String("proto3"),
+		Dependency: []string{"google/protobuf/duration.proto"},
+		EnumType: []*descriptorpb.EnumDescriptorProto{{
+			Name: proto.String("Severity"),
+			Value: []*descriptorpb.EnumValueDescriptorProto{
+				{Name: proto.String("SEVERITY_UNSPECIFIED"), Number: proto.Int32(0)},
+				{Name: proto.String("SEVERITY_FATAL"), Number: proto.Int32(1)},
+				{Name: proto.String("SEVERITY_WARNING"), Number: proto.Int32(2)},
+				{Name: proto.String("SEVERITY_NORMAL"), Number: proto.Int32(3)},
+			},
+		}},
+		MessageType: []*descriptorpb.DescriptorProto{
+			{Name: proto.String("RunFunctionResponse"), Field: []*descriptorpb.FieldDescriptorProto{
+				field("meta", 1, msg, ".apiextensions.fn.proto.v1.ResponseMeta", false),
+				field("results", 3, msg, ".apiextensions.fn.proto.v1.Result", true),
+			}},
+			{Name: proto.String("ResponseMeta"), Field: []*descriptorpb.FieldDescriptorProto{
+				field("tag", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING, "", false),
+				field("ttl", 2, msg, ".google.protobuf.Duration", false),
+			}},
+			{Name: proto.String("Result"), Field: []*descriptorpb.FieldDescriptorProto{
+				field("severity", 1, descriptorpb.FieldDescriptorProto_TYPE_ENUM, ".apiextensions.fn.proto.v1.Severity", false),
+				field("message", 2, descriptorpb.FieldDescriptorProto_TYPE_STRING, "", false),
+			}},
+		},
+	}, protoregistry.GlobalFiles)
+	if err != nil {
+		t.Fatalf("protodesc.NewFile(...): %v", err)
+	}
+
+	types := &protoregistry.Types{}
+	if err := types.RegisterMessage(dynamicpb.NewMessageType(fd.Messages().ByName("RunFunctionResponse"))); err != nil {
+		t.Fatalf("RegisterMessage(...): %v", err)
+	}
+	return types
+}
+
+func TestDecodeMessagePayload(t *testing.T) {
+	types := responseTypes(t)
+
+	tests := []struct {
+		name      string
+		types     protoregistry.MessageTypeResolver
+		eventType string
+		data      string
+		want      any
+	}{
+		{
+			name:      "typed response is normalized",
+			types:     types,
+			eventType: EventTypeResponse,
+			data:      `{"meta":{"tag":"abc","ttl":"60.000s"},"results":[{"severity":1,"message":"boom"}]}`,
+			want: map[string]any{
+				"meta":    map[string]any{"tag": "abc", "ttl": "60s"},
+				"results": []any{map[string]any{"severity": "SEVERITY_FATAL", "message": "boom"}},
+			},
+		},
+		{
+			name:      "unknown fields fall back to generic JSON",
+			types:     types,
+			eventType: EventTypeResponse,
+			data:      `{"results":[{"severity":1}],"fromTheFuture":true}`,
+			want: map[string]any{
+				"results":       []any{map[string]any{"severity": 1.0}},
+				"fromTheFuture": true,
+			},
+		},
+		{
+			name:      "unresolvable type falls back to generic JSON",
+			types:     types,
+			eventType: EventTypeRequest,
+			data:      `{"meta":{"tag":"abc"}}`,
+			want:      map[string]any{"meta": map[string]any{"tag": "abc"}},
+		},
+		{
+			name:      "no resolver falls back to generic JSON",
+			eventType: EventTypeResponse,
+			data:      `{"results":[{"severity":1}]}`,
+			want:      map[string]any{"results": []any{map[string]any{"severity": 1.0}}},
+		},
+		{
+			name:      "invalid JSON falls back to the raw string",
+			types:     types,
+			eventType: EventTypeResponse,
+			data:      `{not valid`,
+			want:      `{not valid`,
+		},
+		{
+			name:      "empty payload",
+			types:     types,
+			eventType: EventTypeResponse,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := decodeMessagePayload(tt.types, tt.eventType, []byte(tt.data))
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("decodeMessagePayload(...) mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,443 @@
+/*
+Copyright 2026 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+this file except in compliance with the License. You may obtain a copy of the
+License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed
+under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+CONDITIONS OF ANY KIND, either express or implied. See the License for the
+specific language governing permissions and limitations under the License.
+*/
+
+package server
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"slices"
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+
+	pipelinev1alpha1 "github.com/crossplane/crossplane-runtime/v2/apis/pipelineinspector/proto/v1alpha1"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/logging"
+)
+
+// EventTypePipeline is the type of events that summarize a whole pipeline run.
+const EventTypePipeline = "PIPELINE"
+
+// severityFatal is the severity of a function result that stops the pipeline.
+const severityFatal = "SEVERITY_FATAL"
+
+// A Pipeline is the payload of a PIPELINE event. It summarizes every step of a
+// single pipeline run, for example one reconcile of a composite resource.
+type Pipeline struct {
+	// Steps in the order they ran.
+	Steps []PipelineStep
+
+	// Duration between the first step's request and the last step's response.
+	Duration time.Duration
+
+	// Desired state returned by the last step that returned a response.
+	Desired any
+
+	// Incomplete is true if the pipeline was written because it was idle for
+	// too long, rather than because its last step responded.
+	Incomplete bool
+}
+
+// A PipelineStep summarizes a single step of a Pipeline.
+type PipelineStep struct {
+	StepName     string
+	StepIndex    int32
+	Iteration    int32
+	FunctionName string
+	Duration     time.Duration
+	Error        string
+	Incomplete   bool
+}
+
+// MarshalJSON marshals the pipeline, rendering durations as strings such as
+// "1.5s" and omitting empty fields.
+func (p *Pipeline) MarshalJSON() ([]byte, error) {
+	steps := make([]map[string]any, 0, len(p.Steps))
+	for _, s := range p.Steps {
+		step := map[string]any{
+			"stepName":     s.StepName,
+			"stepIndex":    s.StepIndex,
+			"iteration":    s.Iteration,
+			"functionName": s.FunctionName,
+			"duration":     s.Duration.String(),
+		}
+		if s.Error != "" {
+			step["error"] = s.Error
+		}
+		if s.Incomplete {
+			step["incomplete"] = true
+		}
+		steps = append(steps, step)
+	}
+
+	pipeline := map[string]any{
+		"steps":    steps,
+		"duration": p.Duration.String(),
+	}
+	if p.Desired != nil {
+		pipeline["desired"] = p.Desired
+	}
+	if p.Incomplete {
+		pipeline["incomplete"] = true
+	}
+	return json.Marshal(pipeline)
+}
+
+// A PipelineOption configures a PipelineSink.
+type PipelineOption func(*PipelineSink)
+
+// WithPipelineTimeout sets how long a pipeline run may be idle before it is
+// written as an incomplete pipeline (default: 30s).
+func WithPipelineTimeout(d time.Duration) PipelineOption {
+	return func(s *PipelineSink) {
+		s.timeout = d
+	}
+}
+
+// WithPipelineLogger sets the logger used to report sink errors.
+func WithPipelineLogger(l logging.Logger) PipelineOption {
+	return func(s *PipelineSink) {
+		s.log = l
+	}
+}
+
+// pipelineKey identifies a single pipeline run.
+type pipelineKey struct {
+	traceID    string
+	contextUID string
+}
+
+type pipelineRun struct {
+	steps   []*Event
+	updated time.Time
+
+	// requirements returned by the latest iteration of each step.
+	requirements map[int32]any
+}
+
+// A PipelineSink groups STEP events into pipeline runs, and writes a PIPELINE
+// event summarizing each run to another sink. Runs are identified by trace ID
+// and composite resource or operation UID. All events are also written to the
+// other sink unchanged.
+//
+// StepMeta doesn't say how many steps a pipeline has, so the sink learns the
+// index of the last step of each Composition or Operation from the runs it has
+// finished without an error or fatal result. A run that started at the first
+// step and whose every step responded sets the index, so it goes down when a
+// Composition's steps are removed. Other runs can only raise it. A run is
+// complete when:
+//
+//   - A step returns an error or a fatal result, which stops the pipeline.
+//   - The last known step responds without requesting new required resources,
+//     which would cause it to run again.
+//
+// Runs that don't complete, including the first run of each Composition or
+// Operation, are written as incomplete once they've been idle for the pipeline
+// timeout.
+type PipelineSink struct {
+	wrappedSink
+
+	timeout time.Duration
+	log     logging.Logger
+	now     func() time.Time
+
+	mu       sync.Mutex
+	runs     map[pipelineKey]*pipelineRun
+	lastStep map[string]int32
+
+	stop chan struct{}
+	done chan struct{}
+	once sync.Once
+}
+
+// NewPipelineSink returns a sink that writes PIPELINE events summarizing the
+// STEP events written to it. It starts a goroutine that writes idle runs as
+// incomplete pipelines.
+func NewPipelineSink(s Sink, opts ...PipelineOption) *PipelineSink {
+	p := &PipelineSink{
+		wrappedSink: wrappedSink{sink: s},
+		timeout:     30 * time.Second,
+		log:         logging.NewNopLogger(),
+		now:         time.Now,
+		runs:        make(map[pipelineKey]*pipelineRun),
+		lastStep:    make(map[string]int32),
+		stop:        make(chan struct{}),
+		done:        make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	go p.sweep()
+	return p
+}
+
+// Write the supplied event, then add it to its pipeline run if it's a STEP
+// event. Writes a PIPELINE event if the step completes its run.
+func (p *PipelineSink) Write(e *Event) error {
+	err := p.sink.Write(e)
+	if e.Type != EventTypeStep {
+		return err
+	}
+
+	k := pipelineKey{traceID: e.Meta.GetTraceId(), contextUID: contextUID(e.Meta)}
+	p.mu.Lock()
+	run, ok := p.runs[k]
+	if !ok {
+		run = &pipelineRun{requirements: make(map[int32]any)}
+		p.runs[k] = run
+	}
+	run.steps = append(run.steps, e)
+	run.updated = p.now()
+	complete := p.completes(run, e)
+	if complete {
+		p.finish(k, run)
+	}
+	p.mu.Unlock()
+
+	if complete {
+		err = errors.Join(err, p.sink.Write(newPipelineEvent(run.steps, false)))
+	}
+	return err
+}
+
+// completes returns true if the supplied step completes the supplied run. It
+// must be called with the lock held.
+func (p *PipelineSink) completes(run *pipelineRun, e *Event) bool {
+	step, _ := e.Payload.(*Step)
+	if step == nil {
+		return false
+	}
+	if e.Error != "" || hasFatalResult(step.Response) {
+		return true
+	}
+	if step.Incomplete {
+		return false
+	}
+
+	idx := e.Meta.GetStepIndex()
+	last, known := p.lastStep[contextName(e.Meta)]
+	if !known || idx < last {
+		return false
+	}
+
+	// A step that requests different required resources than its previous
+	// iteration will run again.
+	rq := field(step.Response, "requirements")
+	prev, ok := run.requirements[idx]
+	run.requirements[idx] = rq
+	return isEmpty(rq) || (ok && reflect.DeepEqual(prev, rq))
+}
+
+// finish removes the supplied run, and learns the index of the last step of its
+// Composition or Operation from it. It must be called with the lock held.
+func (p *PipelineSink) finish(k pipelineKey, run *pipelineRun) {
+	delete(p.runs, k)
+	if len(run.steps) == 0 {
+		return
+	}
+
+	var last int32
+	first, responded := false, true
+	for _, e := range run.steps {
+		step, _ := e.Payload.(*Step)
+		// Runs stopped by an error or fatal result end early.
+		if e.Error != "" || (step != nil && hasFatalResult(step.Response)) {
+			return
+		}
+		idx := e.Meta.GetStepIndex()
+		last = max(last, idx)
+		first = first || idx == 0
+		responded = responded && step != nil && !step.Incomplete
+	}
+
+	name := contextName(run.steps[0].Meta)
+	if known, ok := p.lastStep[name]; !ok || (first && responded) || last > known {
+		p.lastStep[name] = last
+	}
+}
+
+// Close writes all pending runs as incomplete pipelines, then closes the
+// underlying sink if it implements io.Closer.
+func (p *PipelineSink) Close() error {
+	p.once.Do(func() { close(p.stop) })
+	<-p.done
+
+	p.mu.Lock()
+	runs := make([]*pipelineRun, 0, len(p.runs))
+	for k, run := range p.runs {
+		runs = append(runs, run)
+		p.finish(k, run)
+	}
+	p.mu.Unlock()
+
+	errs := make([]error, 0, len(runs)+1)
+	for _, run := range runs {
+		errs = append(errs, p.sink.Write(newPipelineEvent(run.steps, true)))
+	}
+	errs = append(errs, CloseSink(p.sink))
+	return errors.Join(errs...)
+}
+
+// sweep periodically writes runs that have been idle longer than the timeout
+// as incomplete pipelines.
+func (p *PipelineSink) sweep() {
+	defer close(p.done)
+
+	t := time.NewTicker(max(p.timeout/4, 10*time.Millisecond))
+	defer t.Stop()
+
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-t.C:
+			for _, steps := range p.expired() {
+				if err := p.sink.Write(newPipelineEvent(steps, true)); err != nil {
+					p.log.Debug("Cannot write incomplete pipeline", "error", err)
+				}
+			}
+		}
+	}
+}
+
+// expired removes and returns the steps of runs that have been idle longer
+// than the timeout.
+func (p *PipelineSink) expired() [][]*Event {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var expired [][]*Event
+	now := p.now()
+	for k, run := range p.runs {
+		if now.Sub(run.updated) >= p.timeout {
+			expired = append(expired, run.steps)
+			p.finish(k, run)
+		}
+	}
+	return expired
+}
+
+// newPipelineEvent returns a PIPELINE event summarizing the supplied STEP
+// events. Its meta identifies the run, but not any individual step.
+func newPipelineEvent(steps []*Event, incomplete bool) *Event {
+	slices.SortStableFunc(steps, func(a, b *Event) int {
+		return cmp.Or(
+			cmp.Compare(a.Meta.GetStepIndex(), b.Meta.GetStepIndex()),
+			cmp.Compare(a.Meta.GetIteration(), b.Meta.GetIteration()),
+		)
+	})
+
+	pl := &Pipeline{Steps: make([]PipelineStep, 0, len(steps)), Incomplete: incomplete}
+	var start, end time.Time
+	for _, e := range steps {
+		step, _ := e.Payload.(*Step)
+		ps := PipelineStep{
+			StepName:     e.Meta.GetStepName(),
+			StepIndex:    e.Meta.GetStepIndex(),
+			Iteration:    e.Meta.GetIteration(),
+			FunctionName: e.Meta.GetFunctionName(),
+			Error:        e.Error,
+		}
+		if step != nil {
+			ps.Duration = step.Duration
+			ps.Incomplete = step.Incomplete
+			if d := field(step.Response, "desired"); d != nil {
+				pl.Desired = d
+			}
+		}
+		pl.Steps = append(pl.Steps, ps)
+
+		if ts := e.Meta.GetTimestamp(); ts != nil {
+			if start.IsZero() || ts.AsTime().Before(start) {
+				start = ts.AsTime()
+			}
+			if t := ts.AsTime().Add(ps.Duration); t.After(end) {
+				end = t
+			}
+		}
+	}
+	if !start.IsZero() {
+		pl.Duration = end.Sub(start)
+	}
+
+	// Strip the step specific fields from the first step's meta.
+	meta := &pipelinev1alpha1.StepMeta{}
+	if len(steps) > 0 {
+		first := steps[0].Meta
+		meta = &pipelinev1alpha1.StepMeta{
+			Timestamp: first.GetTimestamp(),
+			TraceId:   first.GetTraceId(),
+		}
+		switch ctx := first.GetContext().(type) {
+		case *pipelinev1alpha1.StepMeta_CompositionMeta:
+			meta.Context = &pipelinev1alpha1.StepMeta_CompositionMeta{CompositionMeta: proto.CloneOf(ctx.CompositionMeta)}
+		case *pipelinev1alpha1.StepMeta_OperationMeta:
+			meta.Context = &pipelinev1alpha1.StepMeta_OperationMeta{OperationMeta: proto.CloneOf(ctx.OperationMeta)}
+		}
+	}
+
+	return &Event{Type: EventTypePipeline, Meta: meta, Payload: pl}
+}
+
+// contextName returns the name of the Composition or Operation that ran the
+// supplied step.
+func contextName(meta *pipelinev1alpha1.StepMeta) string {
+	if name := meta.GetCompositionMeta().GetCompositionName(); name != "" {
+		return name
+	}
+	return meta.GetOperationMeta().GetOperationName()
+}
+
+// hasFatalResult returns true if the supplied decoded RunFunctionResponse has
+// a fatal result.
+func hasFatalResult(rsp any) bool {
+	_, ok := fatalResult(rsp)
+	return ok
+}
+
+// fatalResult returns the message of the first fatal result of the supplied
+// decoded RunFunctionResponse, and true if it has one.
+func fatalResult(rsp any) (string, bool) {
+	results, _ := field(rsp, "results").([]any)
+	for _, r := range results {
+		if field(r, "severity") == severityFatal {
+			return stringField(r, "message"), true
+		}
+	}
+	return "", false
+}
+
+// field returns the named field of the supplied decoded JSON object, or nil if
+// it isn't an object or doesn't have the field.
+func field(v any, name string) any {
+	m, ok := v.(map[string]any)
+	if !ok {
+		return nil
+	}
+	return m[name]
+}
+
+// isEmpty returns true if the supplied decoded JSON value is nil or an empty
+// object.
+func isEmpty(v any) bool {
+	if v == nil {
+		return true
+	}
+	m, ok := v.(map[string]any)
+	return ok && len(m) == 0
+}
@@ -0,0 +1,253 @@
+/*
+Copyright 2026 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+this file except in compliance with the License. You may obtain a copy of the
+License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed
+under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+CONDITIONS OF ANY KIND, either express or implied. See the License for the
+specific language governing permissions and limitations under the License.
+*/
+
+package server
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pipelinev1alpha1 "github.com/crossplane/crossplane-runtime/v2/apis/pipelineinspector/proto/v1alpha1"
+)
+
+var pipelineStart = time.Date(2026, 1, 15, 10, 30, 0, 0, time.UTC)
+
+// stepEvent returns a STEP event for the supplied trace and step.
+func stepEvent(trace string, idx, iteration int32, rsp any, errMsg string) *Event {
+	return &Event{
+		Type: EventTypeStep,
+		Meta: &pipelinev1alpha1.StepMeta{
+			TraceId:      trace,
+			StepIndex:    idx,
+			Iteration:    iteration,
+			StepName:     "step",
+			FunctionName: "function",
+			Timestamp:    timestamppb.New(pipelineStart.Add(time.Duration(idx) * time.Second)),
+			Context: &pipelinev1alpha1.StepMeta_CompositionMeta{
+				CompositionMeta: &pipelinev1alpha1.CompositionMeta{CompositionName: "my-composition", CompositeResourceUid: "uid-1"},
+			},
+		},
+		Payload: &Step{Response: rsp, Duration: 100 * time.Millisecond},
+		Error:   errMsg,
+	}
+}
+
+// pipelines returns the PIPELINE payloads written to the supplied sink.
+func pipelines(rec *recordingSink) []*Pipeline {
+	var out []*Pipeline
+	for _, e := range rec.got() {
+		if e.Type == EventTypePipeline {
+			out = append(out, e.Payload.(*Pipeline))
+		}
+	}
+	return out
+}
+
+func TestPipelineSink(t *testing.T) {
+	desired := func(v string) map[string]any {
+		return map[string]any{"desired": map[string]any{"composite": v}}
+	}
+	fatal := map[string]any{"results": []any{map[string]any{"severity": "SEVERITY_FATAL"}}}
+	requires := map[string]any{"requirements": map[string]any{"extraResources": map[string]any{"a": 1}}}
+
+	rec := &recordingSink{}
+	p := NewPipelineSink(rec, WithPipelineTimeout(time.Hour))
+	write := func(events ...*Event) {
+		t.Helper()
+		for _, e := range events {
+			if err := p.Write(e); err != nil {
+				t.Fatalf("Write failed: %v", err)
+			}
+		}
+	}
+
+	// The first run teaches the sink the pipeline has three steps. It's
+	// written as incomplete once it's idle.
+	write(stepEvent("t1", 0, 0, desired("0"), ""), stepEvent("t1", 1, 0, desired("1"), ""), stepEvent("t1", 2, 0, desired("2"), ""))
+	expire(p, rec)
+
+	// The second run completes when its last step responds. Steps are
+	// ordered by index and iteration.
+	write(stepEvent("t2", 1, 0, desired("1"), ""), stepEvent("t2", 0, 0, desired("0"), ""), stepEvent("t2", 2, 0, desired("2"), ""))
+
+	// The third run stops early because a step returns a fatal result.
+	write(stepEvent("t3", 0, 0, fatal, ""))
+
+	// The fourth run stops early because a step returns an error.
+	write(stepEvent("t4", 0, 0, nil, "boom"))
+
+	// The fifth run's last step runs again because it requests resources.
+	write(stepEvent("t5", 0, 0, nil, ""), stepEvent("t5", 1, 0, nil, ""), stepEvent("t5", 2, 0, requires, ""))
+	if got := len(pipelines(rec)); got != 4 {
+		t.Fatalf("expected 4 pipelines before last step iterates, got %d", got)
+	}
+	write(stepEvent("t5", 2, 1, requires, ""))
+
+	// The sixth run never completes, so it's flushed on close.
+	write(stepEvent("t6", 0, 0, desired("0"), ""))
+
+	if err := p.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	step := func(idx, iteration int32, errMsg string) PipelineStep {
+		return PipelineStep{StepName: "step", StepIndex: idx, Iteration: iteration, FunctionName: "function", Duration: 100 * time.Millisecond, Error: errMsg}
+	}
+	want := []*Pipeline{
+		{Steps: []PipelineStep{step(0, 0, ""), step(1, 0, ""), step(2, 0, "")}, Duration: 2100 * time.Millisecond, Desired: map[string]any{"composite": "2"}, Incomplete: true},
+		{Steps: []PipelineStep{step(0, 0, ""), step(1, 0, ""), step(2, 0, "")}, Duration: 2100 * time.Millisecond, Desired: map[string]any{"composite": "2"}},
+		{Steps: []PipelineStep{step(0, 0, "")}, Duration: 100 * time.Millisecond},
+		{Steps: []PipelineStep{step(0, 0, "boom")}, Duration: 100 * time.Millisecond},
+		{Steps: []PipelineStep{step(0, 0, ""), step(1, 0, ""), step(2, 0, ""), step(2, 1, "")}, Duration: 2100 * time.Millisecond},
+		{Steps: []PipelineStep{step(0, 0, "")}, Duration: 100 * time.Millisecond, Desired: map[string]any{"composite": "0"}, Incomplete: true},
+	}
+	if diff := cmp.Diff(want, pipelines(rec)); diff != "" {
+		t.Errorf("pipelines mismatch (-want +got):\n%s", diff)
+	}
+
+	// Every STEP event is also passed through.
+	if got := len(rec.got()) - len(want); got != 13 {
+		t.Errorf("expected 13 STEP events to be passed through, got %d", got)
+	}
+}
+
+// expire writes the supplied sink's runs as incomplete pipelines, as if they
+// had been idle for longer than the timeout.
+func expire(p *PipelineSink, rec *recordingSink) {
+	p.mu.Lock()
+	now := p.now
+	p.now = func() time.Time { return now().Add(p.timeout) }
+	p.mu.Unlock()
+
+	for _, steps := range p.expired() {
+		_ = rec.Write(newPipelineEvent(steps, true))
+	}
+
+	p.mu.Lock()
+	p.now = now
+	p.mu.Unlock()
+}
+
+func TestPipelineSink_LastStep(t *testing.T) {
+	steps := func(trace string, idx ...int32) []*Event {
+		events := make([]*Event, 0, len(idx))
+		for _, i := range idx {
+			events = append(events, stepEvent(trace, i, 0, nil, ""))
+		}
+		return events
+	}
+	lengths := func(rec *recordingSink) []int {
+		var got []int
+		for _, pl := range pipelines(rec) {
+			got = append(got, len(pl.Steps))
+		}
+		return got
+	}
+
+	tests := []struct {
+		name string
+		runs [][]*Event
+		want []int
+	}{
+		{
+			// The failed run doesn't teach the sink the pipeline has one
+			// step, so the next run isn't split into one pipeline per step.
+			name: "FailedRunIgnored",
+			runs: [][]*Event{{stepEvent("t1", 0, 0, nil, "boom")}, steps("t2", 0, 1, 2), steps("t3", 0, 1, 2)},
+			want: []int{1, 3, 3},
+		},
+		{
+			// A whole run that ends earlier teaches the sink a step was
+			// removed, so later runs complete at the new last step.
+			name: "StepRemoved",
+			runs: [][]*Event{steps("t1", 0, 1, 2), steps("t2", 0, 1, 2), steps("t3", 0, 1), steps("t4", 0, 1)},
+			want: []int{3, 3, 2, 2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := &recordingSink{}
+			p := NewPipelineSink(rec, WithPipelineTimeout(time.Hour))
+			defer func() { _ = p.Close() }()
+
+			for i, run := range tt.runs {
+				for _, e := range run {
+					_ = p.Write(e)
+				}
+				// Runs that don't complete are written once they're idle,
+				// except the last, which must complete.
+				if i < len(tt.runs)-1 {
+					expire(p, rec)
+				}
+			}
+			if diff := cmp.Diff(tt.want, lengths(rec)); diff != "" {
+				t.Errorf("pipeline lengths mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestPipelineSink_Timeout(t *testing.T) {
+	rec := &recordingSink{}
+	p := NewPipelineSink(rec, WithPipelineTimeout(20*time.Millisecond))
+	defer func() { _ = p.Close() }()
+
+	_ = p.Write(stepEvent("t1", 0, 0, nil, ""))
+
+	deadline := time.Now().Add(5 * time.Second)
+	for len(pipelines(rec)) == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	got := pipelines(rec)
+	if len(got) != 1 || !got[0].Incomplete {
+		t.Fatalf("expected one incomplete pipeline, got %+v", got)
+	}
+	for _, e := range rec.got() {
+		if e.Type != EventTypePipeline {
+			continue
+		}
+		if e.Meta.GetTraceId() != "t1" || e.Meta.GetCompositionMeta().GetCompositeResourceUid() != "uid-1" || e.Meta.GetStepName() != "" {
+			t.Errorf("expected pipeline meta to identify the run only, got %v", e.Meta)
+		}
+	}
+}
+
+func TestPipelineMarshalJSON(t *testing.T) {
+	p := &Pipeline{
+		Steps: []PipelineStep{
+			{StepName: "a", StepIndex: 0, FunctionName: "fn-a", Duration: time.Second},
+			{StepName: "b", StepIndex: 1, Iteration: 1, FunctionName: "fn-b", Duration: 500 * time.Millisecond, Error: "boom"},
+		},
+		Duration: 2 * time.Second,
+		Desired:  map[string]any{"composite": map[string]any{}},
+	}
+
+	got, err := json.Marshal(p)
+	if err != nil {
+		t.Fatalf("json.Marshal failed: %v", err)
+	}
+	want := `{"desired":{"composite":{}},"duration":"2s","steps":[` +
+		`{"duration":"1s","functionName":"fn-a","iteration":0,"stepIndex":0,"stepName":"a"},` +
+		`{"duration":"500ms","error":"boom","functionName":"fn-b","iteration":1,"stepIndex":1,"stepName":"b"}]}`
+	if diff := cmp.Diff(want, string(got)); diff != "" {
+		t.Errorf("MarshalJSON mismatch (-want +got):\n%s", diff)
+	}
+}
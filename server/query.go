@@ -0,0 +1,257 @@
+/*
+Copyright 2026 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+this file except in compliance with the License. You may obtain a copy of the
+License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed
+under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+CONDITIONS OF ANY KIND, either express or implied. See the License for the
+specific language governing permissions and limitations under the License.
+*/
+
+package server
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Query parameters understood by ParseQuery.
+const (
+	queryType         = "type"
+	queryXRAPIVersion = "xrApiVersion"
+	queryXRKind       = "xrKind"
+	queryXRName       = "xrName"
+	queryXRNamespace  = "xrNamespace"
+	queryXRUID        = "xrUid"
+	queryComposition  = "composition"
+	queryOperation    = "operation"
+	queryOperationUID = "operationUid"
+	queryFunction     = "function"
+	queryStep         = "step"
+	queryError        = "error"
+	queryTraceID      = "traceId"
+	querySpanID       = "spanId"
+	querySince        = "since"
+	queryUntil        = "until"
+	queryLimit        = "limit"
+)
+
+// A Query selects events by their type and metadata. Empty fields match all
+// events.
+type Query struct {
+	// Type of event, for example REQUEST.
+	Type string
+
+	// XRAPIVersion, XRKind, XRName, XRNamespace and XRUID match the composite
+	// resource being reconciled.
+	XRAPIVersion string
+	XRKind       string
+	XRName       string
+	XRNamespace  string
+	XRUID        string
+
+	// CompositionName matches the Composition that defines the pipeline.
+	CompositionName string
+
+	// OperationName and OperationUID match the Operation that defines the
+	// pipeline.
+	OperationName string
+	OperationUID  string
+
+	// FunctionName matches the function that was called.
+	FunctionName string
+
+	// StepName matches the pipeline step that called the function.
+	StepName string
+
+	// HasError matches events with (true) or without (false) an error. Nil
+	// matches both.
+	HasError *bool
+
+	// TraceID matches all events of a pipeline run.
+	TraceID string
+
+	// SpanID matches all events of a single function call.
+	SpanID string
+
+	// Since and Until match events with a timestamp in the range [Since,
+	// Until).
+	Since time.Time
+	Until time.Time
+
+	// Limit is the maximum number of events to return. Queries return the
+	// newest events when limited. Zero means no limit.
+	Limit int
+}
+
+// Match returns true if the supplied event matches the query. It ignores the
+// query's limit.
+func (q Query) Match(e *Event) bool {
+	m := e.Meta
+	cm := m.GetCompositionMeta()
+	om := m.GetOperationMeta()
+	switch {
+	case q.Type != "" && !strings.EqualFold(q.Type, e.Type):
+		return false
+	case q.XRAPIVersion != "" && q.XRAPIVersion != cm.GetCompositeResourceApiVersion():
+		return false
+	case q.XRKind != "" && q.XRKind != cm.GetCompositeResourceKind():
+		return false
+	case q.XRName != "" && q.XRName != cm.GetCompositeResourceName():
+		return false
+	case q.XRNamespace != "" && q.XRNamespace != cm.GetCompositeResourceNamespace():
+		return false
+	case q.XRUID != "" && q.XRUID != cm.GetCompositeResourceUid():
+		return false
+	case q.CompositionName != "" && q.CompositionName != cm.GetCompositionName():
+		return false
+	case q.OperationName != "" && q.OperationName != om.GetOperationName():
+		return false
+	case q.OperationUID != "" && q.OperationUID != om.GetOperationUid():
+		return false
+	case q.FunctionName != "" && q.FunctionName != m.GetFunctionName():
+		return false
+	case q.StepName != "" && q.StepName != m.GetStepName():
+		return false
+	case q.HasError != nil && *q.HasError != (e.Error != ""):
+		return false
+	case q.TraceID != "" && q.TraceID != m.GetTraceId():
+		return false
+	case q.SpanID != "" && q.SpanID != m.GetSpanId():
+		return false
+	}
+
+	if q.Since.IsZero() && q.Until.IsZero() {
+		return true
+	}
+	ts := m.GetTimestamp().AsTime()
+	if !q.Since.IsZero() && ts.Before(q.Since) {
+		return false
+	}
+	return q.Until.IsZero() || ts.Before(q.Until)
+}
+
+// Values encodes the query as URL query parameters understood by ParseQuery.
+func (q Query) Values() url.Values {
+	v := url.Values{}
+	set := func(k, val string) {
+		if val != "" {
+			v.Set(k, val)
+		}
+	}
+	set(queryType, q.Type)
+	set(queryXRAPIVersion, q.XRAPIVersion)
+	set(queryXRKind, q.XRKind)
+	set(queryXRName, q.XRName)
+	set(queryXRNamespace, q.XRNamespace)
+	set(queryXRUID, q.XRUID)
+	set(queryComposition, q.CompositionName)
+	set(queryOperation, q.OperationName)
+	set(queryOperationUID, q.OperationUID)
+	set(queryFunction, q.FunctionName)
+	set(queryStep, q.StepName)
+	if q.HasError != nil {
+		v.Set(queryError, strconv.FormatBool(*q.HasError))
+	}
+	set(queryTraceID, q.TraceID)
+	set(querySpanID, q.SpanID)
+	if !q.Since.IsZero() {
+		v.Set(querySince, q.Since.Format(time.RFC3339Nano))
+	}
+	if !q.Until.IsZero() {
+		v.Set(queryUntil, q.Until.Format(time.RFC3339Nano))
+	}
+	if q.Limit > 0 {
+		v.Set(queryLimit, strconv.Itoa(q.Limit))
+	}
+	return v
+}
+
+// ParseQuery parses a query from URL query parameters. Times may be RFC 3339
+// timestamps, or durations such as 5m that are interpreted relative to now.
+func ParseQuery(v url.Values, now time.Time) (Query, error) {
+	q := Query{
+		Type:            strings.ToUpper(v.Get(queryType)),
+		XRAPIVersion:    v.Get(queryXRAPIVersion),
+		XRKind:          v.Get(queryXRKind),
+		XRName:          v.Get(queryXRName),
+		XRNamespace:     v.Get(queryXRNamespace),
+		XRUID:           v.Get(queryXRUID),
+		CompositionName: v.Get(queryComposition),
+		OperationName:   v.Get(queryOperation),
+		OperationUID:    v.Get(queryOperationUID),
+		FunctionName:    v.Get(queryFunction),
+		StepName:        v.Get(queryStep),
+		TraceID:         v.Get(queryTraceID),
+		SpanID:          v.Get(querySpanID),
+	}
+
+	var err error
+	if e := v.Get(queryError); e != "" {
+		b, err := strconv.ParseBool(e)
+		if err != nil {
+			return Query{}, fmt.Errorf("invalid %s: must be true or false", queryError)
+		}
+		q.HasError = &b
+	}
+	if q.Since, err = ParseQueryTime(v.Get(querySince), now); err != nil {
+		return Query{}, fmt.Errorf("invalid %s: %w", querySince, err)
+	}
+	if q.Until, err = ParseQueryTime(v.Get(queryUntil), now); err != nil {
+		return Query{}, fmt.Errorf("invalid %s: %w", queryUntil, err)
+	}
+	if l := v.Get(queryLimit); l != "" {
+		if q.Limit, err = strconv.Atoi(l); err != nil || q.Limit < 0 {
+			return Query{}, fmt.Errorf("invalid %s: must be a non-negative integer", queryLimit)
+		}
+	}
+	return q, nil
+}
+
+// ParseMatch parses a query from a comma separated list of KEY=VALUE pairs,
+// for example composition=my-composition,xrKind=XDatabase. Keys are the query
+// parameters understood by ParseQuery, except since, until and limit. An
+// event matches the query if it matches all pairs. Matches are applied before
+// events are paired or aggregated, so the only types they can match are
+// REQUEST and RESPONSE.
+func ParseMatch(s string) (Query, error) {
+	v := url.Values{}
+	for p := range strings.SplitSeq(s, ",") {
+		k, val, ok := strings.Cut(p, "=")
+		if !ok {
+			return Query{}, fmt.Errorf("invalid match %q: must be KEY=VALUE", p)
+		}
+		switch k {
+		case queryType, queryXRAPIVersion, queryXRKind, queryXRName, queryXRNamespace, queryXRUID,
+			queryComposition, queryOperation, queryOperationUID, queryFunction, queryStep, queryError,
+			queryTraceID, querySpanID:
+		default:
+			return Query{}, fmt.Errorf("unsupported match key %q", k)
+		}
+		if t := strings.ToUpper(val); k == queryType && t != EventTypeRequest && t != EventTypeResponse {
+			return Query{}, fmt.Errorf("unsupported match type %q: must be %s or %s", val, EventTypeRequest, EventTypeResponse)
+		}
+		v.Set(k, val)
+	}
+	return ParseQuery(v, time.Time{})
+}
+
+// ParseQueryTime parses an RFC 3339 timestamp, or a duration such as 5m that
+// is interpreted relative to now. An empty string is the zero time.
+func ParseQueryTime(s string, now time.Time) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return now.Add(-d), nil
+	}
+	return time.Parse(time.RFC3339Nano, s)
+}
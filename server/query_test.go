@@ -0,0 +1,222 @@
+/*
+Copyright 2026 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+this file except in compliance with the License. You may obtain a copy of the
+License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed
+under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+CONDITIONS OF ANY KIND, either express or implied. See the License for the
+specific language governing permissions and limitations under the License.
+*/
+
+package server
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pipelinev1alpha1 "github.com/crossplane/crossplane-runtime/v2/apis/pipelineinspector/proto/v1alpha1"
+)
+
+func TestQueryMatch(t *testing.T) {
+	ts := time.Date(2026, 1, 15, 10, 30, 0, 0, time.UTC)
+	xr := &Event{
+		Type: EventTypeRequest,
+		Meta: &pipelinev1alpha1.StepMeta{
+			TraceId:      "trace-1",
+			SpanId:       "span-1",
+			FunctionName: "function-a",
+			StepName:     "step-a",
+			Timestamp:    timestamppb.New(ts),
+			Context: &pipelinev1alpha1.StepMeta_CompositionMeta{
+				CompositionMeta: &pipelinev1alpha1.CompositionMeta{
+					CompositeResourceApiVersion: "example.org/v1",
+					CompositeResourceKind:       "XDatabase",
+					CompositeResourceName:       "my-xr",
+					CompositeResourceNamespace:  "default",
+					CompositeResourceUid:        "uid-1",
+					CompositionName:             "my-composition",
+				},
+			},
+		},
+	}
+	op := &Event{
+		Type:  EventTypeResponse,
+		Error: "boom",
+		Meta: &pipelinev1alpha1.StepMeta{
+			FunctionName: "function-b",
+			Timestamp:    timestamppb.New(ts),
+			Context: &pipelinev1alpha1.StepMeta_OperationMeta{
+				OperationMeta: &pipelinev1alpha1.OperationMeta{OperationName: "my-op", OperationUid: "op-uid"},
+			},
+		},
+	}
+
+	tests := []struct {
+		name   string
+		q      Query
+		wantXR bool
+		wantOp bool
+	}{
+		{name: "empty matches all", q: Query{}, wantXR: true, wantOp: true},
+		{name: "type", q: Query{Type: "request"}, wantXR: true},
+		{name: "xr api version", q: Query{XRAPIVersion: "example.org/v1"}, wantXR: true},
+		{name: "xr kind", q: Query{XRKind: "XDatabase"}, wantXR: true},
+		{name: "xr name", q: Query{XRName: "my-xr"}, wantXR: true},
+		{name: "xr namespace", q: Query{XRNamespace: "other"}},
+		{name: "xr uid", q: Query{XRUID: "uid-2"}},
+		{name: "composition", q: Query{CompositionName: "my-composition"}, wantXR: true},
+		{name: "operation", q: Query{OperationName: "my-op"}, wantOp: true},
+		{name: "operation uid", q: Query{OperationUID: "op-uid"}, wantOp: true},
+		{name: "function", q: Query{FunctionName: "function-b"}, wantOp: true},
+		{name: "step", q: Query{StepName: "step-a"}, wantXR: true},
+		{name: "has error", q: Query{HasError: ptr(true)}, wantOp: true},
+		{name: "no error", q: Query{HasError: ptr(false)}, wantXR: true},
+		{name: "trace", q: Query{TraceID: "trace-1"}, wantXR: true},
+		{name: "span", q: Query{SpanID: "span-1", FunctionName: "function-a"}, wantXR: true},
+		{name: "since inclusive", q: Query{Since: ts}, wantXR: true, wantOp: true},
+		{name: "until exclusive", q: Query{Until: ts}},
+		{name: "in range", q: Query{Since: ts.Add(-time.Minute), Until: ts.Add(time.Minute)}, wantXR: true, wantOp: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.q.Match(xr); got != tt.wantXR {
+				t.Errorf("Match(xr): want %t, got %t", tt.wantXR, got)
+			}
+			if got := tt.q.Match(op); got != tt.wantOp {
+				t.Errorf("Match(op): want %t, got %t", tt.wantOp, got)
+			}
+		})
+	}
+}
+
+func TestParseQuery(t *testing.T) {
+	now := time.Date(2026, 1, 15, 10, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		values  string
+		want    Query
+		wantErr bool
+	}{
+		{
+			name:   "all fields",
+			values: "type=step&xrApiVersion=v&xrKind=k&xrName=my-xr&xrNamespace=ns&xrUid=uid-1&composition=c&operation=o&operationUid=ou&function=f&step=st&error=true&traceId=t&spanId=s&since=2026-01-15T10:00:00Z&until=2026-01-15T10:15:00Z&limit=10",
+			want: Query{
+				Type:            "STEP",
+				XRAPIVersion:    "v",
+				XRKind:          "k",
+				XRName:          "my-xr",
+				XRNamespace:     "ns",
+				XRUID:           "uid-1",
+				CompositionName: "c",
+				OperationName:   "o",
+				OperationUID:    "ou",
+				FunctionName:    "f",
+				StepName:        "st",
+				HasError:        ptr(true),
+				TraceID:         "t",
+				SpanID:          "s",
+				Since:           time.Date(2026, 1, 15, 10, 0, 0, 0, time.UTC),
+				Until:           time.Date(2026, 1, 15, 10, 15, 0, 0, time.UTC),
+				Limit:           10,
+			},
+		},
+		{
+			name:   "relative since",
+			values: "since=5m",
+			want:   Query{Since: now.Add(-5 * time.Minute)},
+		},
+		{
+			name:    "invalid since",
+			values:  "since=yesterday",
+			wantErr: true,
+		},
+		{
+			name:    "invalid error",
+			values:  "error=maybe",
+			wantErr: true,
+		},
+		{
+			name:    "invalid limit",
+			values:  "limit=-1",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, _ := url.ParseQuery(tt.values)
+			got, err := ParseQuery(v, now)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("ParseQuery(%q): expected error, got %+v", tt.values, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseQuery(%q) failed: %v", tt.values, err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("ParseQuery(%q) mismatch (-want +got):\n%s", tt.values, diff)
+			}
+
+			// Values must round trip through ParseQuery.
+			rt, err := ParseQuery(got.Values(), now)
+			if err != nil {
+				t.Fatalf("ParseQuery(Values()) failed: %v", err)
+			}
+			if diff := cmp.Diff(got, rt); diff != "" {
+				t.Errorf("Values() round trip mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestParseMatch(t *testing.T) {
+	tests := []struct {
+		match   string
+		want    Query
+		wantErr bool
+	}{
+		{match: "composition=my-composition", want: Query{CompositionName: "my-composition"}},
+		{match: "xrKind=XDatabase,xrNamespace=default,type=response", want: Query{XRKind: "XDatabase", XRNamespace: "default", Type: "RESPONSE"}},
+		{match: "function=function-a,error=false", want: Query{FunctionName: "function-a", HasError: ptr(false)}},
+		{match: "composition", wantErr: true},
+		{match: "since=5m", wantErr: true},
+		{match: "colour=blue", wantErr: true},
+		{match: "type=STEP", wantErr: true},
+		{match: "type=pipeline", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.match, func(t *testing.T) {
+			got, err := ParseMatch(tt.match)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("ParseMatch(%q): expected error, got %+v", tt.match, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseMatch(%q) failed: %v", tt.match, err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("ParseMatch(%q) mismatch (-want +got):\n%s", tt.match, diff)
+			}
+		})
+	}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
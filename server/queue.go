@@ -0,0 +1,254 @@
+/*
+Copyright 2026 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+this file except in compliance with the License. You may obtain a copy of the
+License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed
+under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+CONDITIONS OF ANY KIND, either express or implied. See the License for the
+specific language governing permissions and limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/crossplane/crossplane-runtime/v2/pkg/logging"
+)
+
+// Drop policies control what a QueueSink does when its queue is full.
+const (
+	// DropNewest discards the event being written.
+	DropNewest = "drop-newest"
+
+	// DropOldest discards the oldest queued event to make room.
+	DropOldest = "drop-oldest"
+
+	// Block waits for room in the queue, up to a timeout, then discards the
+	// event being written.
+	Block = "block"
+)
+
+var (
+	errQueueFull   = errors.New("queue is full: event dropped")
+	errQueueClosed = errors.New("queue is closed: event dropped")
+)
+
+// A QueueOption configures a QueueSink.
+type QueueOption func(*QueueSink)
+
+// WithQueueSize sets the maximum number of queued events (default: 1000).
+func WithQueueSize(n int) QueueOption {
+	return func(q *QueueSink) {
+		q.size = n
+	}
+}
+
+// WithDropPolicy sets what happens when the queue is full (default:
+// DropNewest).
+func WithDropPolicy(p string) QueueOption {
+	return func(q *QueueSink) {
+		q.policy = p
+	}
+}
+
+// WithBlockTimeout sets how long writes wait for room in the queue when using
+// the Block drop policy (default: 1s).
+func WithBlockTimeout(d time.Duration) QueueOption {
+	return func(q *QueueSink) {
+		q.blockTimeout = d
+	}
+}
+
+// WithQueueWorkers sets the number of goroutines that drain the queue
+// (default: 1). Events may be written out of order when using more than one
+// worker.
+func WithQueueWorkers(n int) QueueOption {
+	return func(q *QueueSink) {
+		q.workers = n
+	}
+}
+
+// WithQueueLogger sets the logger used to report sink errors.
+func WithQueueLogger(l logging.Logger) QueueOption {
+	return func(q *QueueSink) {
+		q.log = l
+	}
+}
+
+// A QueueSink writes events to another sink asynchronously. Events are
+// enqueued into a bounded in-memory queue, which is drained by worker
+// goroutines. This keeps slow sinks off the gRPC request path. Workers also
+// decode the payloads of events written by an Inspector, so decoding is off
+// the request path too.
+type QueueSink struct {
+	wrappedSink
+
+	size         int
+	policy       string
+	blockTimeout time.Duration
+	workers      int
+	log          logging.Logger
+
+	events  chan *Event
+	wg      sync.WaitGroup
+	dropped atomic.Uint64
+
+	// stop is closed when Drain gives up waiting for queued events to be
+	// written, and tells workers to discard them.
+	stop     chan struct{}
+	stopOnce sync.Once
+
+	// mu protects closed, and prevents events being sent to a closed channel.
+	mu     sync.RWMutex
+	closed bool
+}
+
+// NewQueueSink returns a sink that asynchronously writes events to the
+// supplied sink. Its workers are started immediately.
+func NewQueueSink(s Sink, opts ...QueueOption) *QueueSink {
+	q := &QueueSink{
+		wrappedSink:  wrappedSink{sink: s},
+		size:         1000,
+		policy:       DropNewest,
+		blockTimeout: time.Second,
+		workers:      1,
+		log:          logging.NewNopLogger(),
+		stop:         make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(q)
+	}
+
+	q.events = make(chan *Event, q.size)
+	for range max(q.workers, 1) {
+		q.wg.Add(1)
+		go q.work()
+	}
+	return q
+}
+
+// Write enqueues the supplied event. It returns an error if the event was
+// dropped.
+func (q *QueueSink) Write(e *Event) error {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	if q.closed {
+		q.dropped.Add(1)
+		return errQueueClosed
+	}
+
+	select {
+	case q.events <- e:
+		return nil
+	default:
+	}
+
+	switch q.policy {
+	case DropOldest:
+		for {
+			select {
+			case q.events <- e:
+				return nil
+			default:
+			}
+			select {
+			case <-q.events:
+				q.dropped.Add(1)
+			default:
+			}
+		}
+	case Block:
+		t := time.NewTimer(q.blockTimeout)
+		defer t.Stop()
+		select {
+		case q.events <- e:
+			return nil
+		case <-t.C:
+		}
+	}
+
+	q.dropped.Add(1)
+	return errQueueFull
+}
+
+// Dropped returns the number of events that have been dropped.
+func (q *QueueSink) Dropped() uint64 {
+	return q.dropped.Load()
+}
+
+// Len returns the number of events currently queued.
+func (q *QueueSink) Len() int {
+	return len(q.events)
+}
+
+// Drain stops accepting events and waits for queued events to be written. It
+// returns the context's error if the context is done before all queued events
+// have been written. Events that are still queued then are dropped, once the
+// events being written have been written.
+func (q *QueueSink) Drain(ctx context.Context) error {
+	q.mu.Lock()
+	if !q.closed {
+		q.closed = true
+		close(q.events)
+	}
+	q.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		q.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		q.stopOnce.Do(func() { close(q.stop) })
+		return ctx.Err()
+	}
+}
+
+// Close drains the queue, then closes the underlying sink if it implements
+// io.Closer. If a call to Drain already timed out Close doesn't wait for the
+// dropped events to be written, but it does wait for the workers to finish
+// the events they're writing, so the underlying sink is never written to
+// after it's closed.
+func (q *QueueSink) Close() error {
+	_ = q.Drain(context.Background())
+	return CloseSink(q.sink)
+}
+
+// decodesPayloads marks the QueueSink as a payloadDecoder.
+func (q *QueueSink) decodesPayloads() {}
+
+func (q *QueueSink) work() {
+	defer q.wg.Done()
+	for e := range q.events {
+		select {
+		case <-q.stop:
+			// Drain has closed the queue, so this returns once the
+			// remaining events have been dropped.
+			q.dropped.Add(1)
+			for range q.events {
+				q.dropped.Add(1)
+			}
+			return
+		default:
+		}
+		decodePayload(e)
+		if err := q.sink.Write(e); err != nil {
+			q.log.Debug("Cannot write event", "type", e.Type, "error", err)
+		}
+	}
+}
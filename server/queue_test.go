@@ -0,0 +1,201 @@
+/*
+Copyright 2026 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+this file except in compliance with the License. You may obtain a copy of the
+License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed
+under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+CONDITIONS OF ANY KIND, either express or implied. See the License for the
+specific language governing permissions and limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// gatedSink records the types of events written to it. Writes block until the
+// gate is opened.
+type gatedSink struct {
+	gate    chan struct{}
+	started chan struct{}
+	once    sync.Once
+
+	mu  sync.Mutex
+	got []string
+}
+
+func newGatedSink() *gatedSink {
+	return &gatedSink{gate: make(chan struct{}), started: make(chan struct{})}
+}
+
+func (s *gatedSink) Write(e *Event) error {
+	s.once.Do(func() { close(s.started) })
+	<-s.gate
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.got = append(s.got, e.Type)
+	return nil
+}
+
+func (s *gatedSink) events() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.got
+}
+
+func TestQueueSink(t *testing.T) {
+	tests := []struct {
+		name        string
+		opts        []QueueOption
+		writes      []string
+		want        []string
+		wantDropped uint64
+	}{
+		{
+			name:   "room in queue",
+			opts:   []QueueOption{WithQueueSize(10)},
+			writes: []string{"a", "b", "c"},
+			want:   []string{"first", "a", "b", "c"},
+		},
+		{
+			name:        "drop newest",
+			opts:        []QueueOption{WithQueueSize(2), WithDropPolicy(DropNewest)},
+			writes:      []string{"a", "b", "c", "d"},
+			want:        []string{"first", "a", "b"},
+			wantDropped: 2,
+		},
+		{
+			name:        "drop oldest",
+			opts:        []QueueOption{WithQueueSize(2), WithDropPolicy(DropOldest)},
+			writes:      []string{"a", "b", "c", "d"},
+			want:        []string{"first", "c", "d"},
+			wantDropped: 2,
+		},
+		{
+			name:        "block times out",
+			opts:        []QueueOption{WithQueueSize(1), WithDropPolicy(Block), WithBlockTimeout(10 * time.Millisecond)},
+			writes:      []string{"a", "b"},
+			want:        []string{"first", "a"},
+			wantDropped: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sink := newGatedSink()
+			q := NewQueueSink(sink, tt.opts...)
+
+			// Wait for the worker to block writing the first event, so the
+			// queue fills deterministically.
+			_ = q.Write(&Event{Type: "first"})
+			<-sink.started
+
+			for _, typ := range tt.writes {
+				_ = q.Write(&Event{Type: typ})
+			}
+			close(sink.gate)
+
+			if err := q.Drain(context.Background()); err != nil {
+				t.Fatalf("Drain failed: %v", err)
+			}
+			if diff := cmp.Diff(tt.want, sink.events()); diff != "" {
+				t.Errorf("written events mismatch (-want +got):\n%s", diff)
+			}
+			if got := q.Dropped(); got != tt.wantDropped {
+				t.Errorf("Dropped(): want %d, got %d", tt.wantDropped, got)
+			}
+		})
+	}
+}
+
+func TestQueueSink_Block(t *testing.T) {
+	sink := newGatedSink()
+	q := NewQueueSink(sink, WithQueueSize(1), WithDropPolicy(Block), WithBlockTimeout(time.Minute))
+
+	_ = q.Write(&Event{Type: "first"})
+	<-sink.started
+	_ = q.Write(&Event{Type: "a"})
+
+	// This write blocks until the worker makes room in the queue.
+	errs := make(chan error)
+	go func() { errs <- q.Write(&Event{Type: "b"}) }()
+	close(sink.gate)
+	if err := <-errs; err != nil {
+		t.Errorf("Write failed: %v", err)
+	}
+
+	_ = q.Drain(context.Background())
+	if diff := cmp.Diff([]string{"first", "a", "b"}, sink.events()); diff != "" {
+		t.Errorf("written events mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestQueueSink_DrainTimeout(t *testing.T) {
+	sink := newGatedSink()
+	q := NewQueueSink(sink)
+	_ = q.Write(&Event{Type: "first"})
+	<-sink.started
+	_ = q.Write(&Event{Type: "second"})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := q.Drain(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Drain: want %v, got %v", context.DeadlineExceeded, err)
+	}
+
+	// Writes after draining are dropped.
+	if err := q.Write(&Event{Type: "late"}); err == nil {
+		t.Error("expected Write after Drain to fail")
+	}
+
+	// Close waits for the wedged worker to finish writing, but not for the
+	// events that were still queued when Drain timed out.
+	closed := make(chan error)
+	go func() { closed <- q.Close() }()
+	select {
+	case <-closed:
+		t.Fatal("Close returned while a worker was writing")
+	case <-time.After(10 * time.Millisecond):
+	}
+	close(sink.gate)
+	if err := <-closed; err != nil {
+		t.Errorf("Close failed: %v", err)
+	}
+
+	if diff := cmp.Diff([]string{"first"}, sink.events()); diff != "" {
+		t.Errorf("written events mismatch (-want +got):\n%s", diff)
+	}
+	if got := q.Dropped(); got != 2 {
+		t.Errorf("Dropped(): want 2, got %d", got)
+	}
+}
+
+func TestQueueSink_DecodesPayloads(t *testing.T) {
+	var got []any
+	q := NewQueueSink(SinkFunc(func(e *Event) error {
+		got = append(got, e.Payload)
+		return nil
+	}))
+
+	_ = q.Write(&Event{Type: EventTypeRequest, Payload: rawPayload(`{"key":"value"}`)})
+	if err := q.Drain(context.Background()); err != nil {
+		t.Fatalf("Drain(...): %v", err)
+	}
+
+	want := []any{map[string]any{"key": "value"}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("written payloads mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,360 @@
+/*
+Copyright 2026 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+this file except in compliance with the License. You may obtain a copy of the
+License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed
+under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+CONDITIONS OF ANY KIND, either express or implied. See the License for the
+specific language governing permissions and limitations under the License.
+*/
+
+package server
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// Redaction actions.
+const (
+	// RedactMask replaces a value with RedactedValue.
+	RedactMask = "mask"
+
+	// RedactHash replaces a value with its HMAC-SHA256, so equal values can
+	// still be correlated without revealing them.
+	RedactHash = "hash"
+
+	// RedactDrop removes a value entirely.
+	RedactDrop = "drop"
+)
+
+// RedactedValue replaces values masked by a Redactor.
+const RedactedValue = "<redacted>"
+
+// A RedactionRule redacts the values at a field path.
+type RedactionRule struct {
+	// Action is RedactMask, RedactHash or RedactDrop.
+	Action string
+
+	// Path to the values to redact, for example
+	// observed.resources[*].resource.spec.password. A * matches any field or
+	// array element. Paths may start with $, as in JSONPath.
+	Path string
+}
+
+// ParseRedactionRule parses a rule of the form ACTION:PATH, for example
+// hash:desired.composite.resource.spec.apiKey.
+func ParseRedactionRule(s string) (RedactionRule, error) {
+	action, path, ok := strings.Cut(s, ":")
+	if !ok {
+		return RedactionRule{}, fmt.Errorf("invalid redaction rule %q: must be ACTION:PATH", s)
+	}
+	r := RedactionRule{Action: strings.ToLower(strings.TrimSpace(action)), Path: strings.TrimSpace(path)}
+	if _, err := compileRedactionRule(r); err != nil {
+		return RedactionRule{}, err
+	}
+	return r, nil
+}
+
+// DefaultRedactionRules returns the rules a Redactor applies unless configured
+// otherwise. They mask credentials passed to functions, and connection
+// details. Redactors also mask the data of Secret resources by default.
+func DefaultRedactionRules() []RedactionRule {
+	return []RedactionRule{
+		{Action: RedactMask, Path: "credentials.*.credentialData.data.*"},
+		{Action: RedactMask, Path: "observed.composite.connectionDetails.*"},
+		{Action: RedactMask, Path: "observed.resources.*.connectionDetails.*"},
+		{Action: RedactMask, Path: "desired.composite.connectionDetails.*"},
+		{Action: RedactMask, Path: "desired.resources.*.connectionDetails.*"},
+	}
+}
+
+// secretPaths are the paths to resources that are masked if they're Secrets.
+func secretPaths() [][]string {
+	return [][]string{
+		{"observed", "resources", "*", "resource"},
+		{"desired", "resources", "*", "resource"},
+		{"requiredResources", "*", "items", "*", "resource"},
+		{"extraResources", "*", "items", "*", "resource"},
+	}
+}
+
+type compiledRule struct {
+	action string
+	path   []string
+}
+
+func compileRedactionRule(r RedactionRule) (compiledRule, error) {
+	switch r.Action {
+	case RedactMask, RedactHash, RedactDrop:
+	default:
+		return compiledRule{}, fmt.Errorf("invalid redaction rule %q: unknown action %q: must be %s, %s or %s", r.Action+":"+r.Path, r.Action, RedactMask, RedactHash, RedactDrop)
+	}
+	path, err := parseFieldPath(r.Path)
+	if err != nil {
+		return compiledRule{}, fmt.Errorf("invalid redaction rule %q: %w", r.Action+":"+r.Path, err)
+	}
+	return compiledRule{action: r.Action, path: path}, nil
+}
+
+// parseFieldPath splits a path such as $.a.b[*]['c.d'] into its segments a,
+// b, * and c.d.
+func parseFieldPath(p string) ([]string, error) {
+	s := strings.TrimPrefix(strings.TrimPrefix(p, "$"), ".")
+	var segs []string
+	for s != "" {
+		switch {
+		case strings.HasPrefix(s, "["):
+			end := strings.Index(s, "]")
+			if end < 0 {
+				return nil, errors.New("unterminated [")
+			}
+			seg := s[1:end]
+			if len(seg) >= 2 && (seg[0] == '\'' || seg[0] == '"') && seg[len(seg)-1] == seg[0] {
+				seg = seg[1 : len(seg)-1]
+			}
+			if seg == "" {
+				return nil, errors.New("empty segment")
+			}
+			segs = append(segs, seg)
+			s = s[end+1:]
+		default:
+			end := strings.IndexAny(s, ".[")
+			if end < 0 {
+				end = len(s)
+			}
+			if end == 0 {
+				return nil, errors.New("empty segment")
+			}
+			segs = append(segs, s[:end])
+			s = s[end:]
+		}
+		if strings.HasPrefix(s, ".") {
+			s = s[1:]
+			if s == "" {
+				return nil, errors.New("trailing .")
+			}
+		}
+	}
+	if len(segs) == 0 {
+		return nil, errors.New("empty path")
+	}
+	return segs, nil
+}
+
+// A RedactorOption configures a Redactor.
+type RedactorOption func(*Redactor)
+
+// WithRedactionRules adds rules to the Redactor.
+func WithRedactionRules(rules ...RedactionRule) RedactorOption {
+	return func(r *Redactor) {
+		r.rules = append(r.rules, rules...)
+	}
+}
+
+// WithHMACKey sets the key used to hash values redacted with RedactHash
+// (default: a random key, so hashes are only comparable within a process).
+func WithHMACKey(key []byte) RedactorOption {
+	return func(r *Redactor) {
+		r.key = key
+	}
+}
+
+// WithoutDefaultRedactions stops the Redactor applying DefaultRedactionRules
+// and masking Secret resources.
+func WithoutDefaultRedactions() RedactorOption {
+	return func(r *Redactor) {
+		r.defaults = false
+	}
+}
+
+// A Redactor removes sensitive values from decoded RunFunctionRequest and
+// RunFunctionResponse payloads.
+type Redactor struct {
+	rules    []RedactionRule
+	key      []byte
+	defaults bool
+
+	compiled []compiledRule
+}
+
+// NewRedactor returns a Redactor that applies the default redactions, then any
+// configured rules. It returns an error if a rule is invalid.
+func NewRedactor(opts ...RedactorOption) (*Redactor, error) {
+	r := &Redactor{defaults: true}
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	rules := r.rules
+	if r.defaults {
+		rules = append(DefaultRedactionRules(), rules...)
+	}
+	for _, rule := range rules {
+		c, err := compileRedactionRule(rule)
+		if err != nil {
+			return nil, err
+		}
+		r.compiled = append(r.compiled, c)
+	}
+
+	if len(r.key) == 0 {
+		r.key = make([]byte, 32)
+		if _, err := rand.Read(r.key); err != nil {
+			return nil, fmt.Errorf("cannot generate HMAC key: %w", err)
+		}
+	}
+	return r, nil
+}
+
+// Redact the supplied payload in place. Payloads that aren't JSON objects or
+// steps are left unchanged.
+func (r *Redactor) Redact(payload any) {
+	switch p := payload.(type) {
+	case *Step:
+		r.Redact(p.Request)
+		r.Redact(p.Response)
+		return
+	case map[string]any:
+	default:
+		return
+	}
+	if r.defaults {
+		for _, p := range secretPaths() {
+			redactPath(payload, p, func(v any) (any, bool) {
+				maskSecret(v)
+				return v, true
+			})
+		}
+	}
+	for _, c := range r.compiled {
+		redactPath(payload, c.path, r.action(c.action))
+	}
+}
+
+func (r *Redactor) action(action string) func(v any) (any, bool) {
+	switch action {
+	case RedactHash:
+		return func(v any) (any, bool) {
+			return r.hash(v), true
+		}
+	case RedactDrop:
+		return func(any) (any, bool) {
+			return nil, false
+		}
+	default:
+		return func(any) (any, bool) {
+			return RedactedValue, true
+		}
+	}
+}
+
+// hash returns the HMAC-SHA256 of the supplied value. Strings are hashed as
+// is; other values are hashed as JSON.
+func (r *Redactor) hash(v any) string {
+	b, ok := v.(string)
+	if !ok {
+		j, _ := json.Marshal(v)
+		b = string(j)
+	}
+	h := hmac.New(sha256.New, r.key)
+	_, _ = h.Write([]byte(b))
+	return "hmac-sha256:" + hex.EncodeToString(h.Sum(nil))
+}
+
+// maskSecret masks the data of the supplied resource if it's a Secret.
+func maskSecret(v any) {
+	res, ok := v.(map[string]any)
+	if !ok || res["apiVersion"] != "v1" || res["kind"] != "Secret" {
+		return
+	}
+	for _, f := range []string{"data", "stringData"} {
+		if data, ok := res[f].(map[string]any); ok {
+			for k := range data {
+				data[k] = RedactedValue
+			}
+		}
+	}
+}
+
+// redactPath calls the supplied action with each value at the supplied path,
+// replacing the value with the one returned. Values are removed if the action
+// returns false. It returns the (possibly replaced) supplied value.
+func redactPath(v any, path []string, action func(v any) (any, bool)) any {
+	seg, rest := path[0], path[1:]
+	switch t := v.(type) {
+	case map[string]any:
+		for k, child := range t {
+			if seg != "*" && seg != k {
+				continue
+			}
+			if len(rest) > 0 {
+				t[k] = redactPath(child, rest, action)
+				continue
+			}
+			if nv, keep := action(child); keep {
+				t[k] = nv
+			} else {
+				delete(t, k)
+			}
+		}
+	case []any:
+		out := t[:0]
+		for i, child := range t {
+			if seg != "*" && seg != strconv.Itoa(i) {
+				out = append(out, child)
+				continue
+			}
+			if len(rest) > 0 {
+				out = append(out, redactPath(child, rest, action))
+				continue
+			}
+			if nv, keep := action(child); keep {
+				out = append(out, nv)
+			}
+		}
+		return out
+	}
+	return v
+}
+
+// A RedactingSink redacts each event's payload before writing it to another
+// sink.
+type RedactingSink struct {
+	wrappedSink
+
+	redactor atomic.Pointer[Redactor]
+}
+
+// NewRedactingSink returns a sink that redacts payloads using the supplied
+// Redactor, then writes events to the supplied sink.
+func NewRedactingSink(s Sink, r *Redactor) *RedactingSink {
+	rs := &RedactingSink{wrappedSink: wrappedSink{sink: s}}
+	rs.redactor.Store(r)
+	return rs
+}
+
+// SetRedactor replaces the Redactor used to redact payloads. Events already
+// being written are redacted by the previous Redactor.
+func (s *RedactingSink) SetRedactor(r *Redactor) {
+	s.redactor.Store(r)
+}
+
+// Write the supplied event, after redacting its payload.
+func (s *RedactingSink) Write(e *Event) error {
+	s.redactor.Load().Redact(e.Payload)
+	return s.sink.Write(e)
+}
@@ -0,0 +1,177 @@
+/*
+Copyright 2026 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+this file except in compliance with the License. You may obtain a copy of the
+License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed
+under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+CONDITIONS OF ANY KIND, either express or implied. See the License for the
+specific language governing permissions and limitations under the License.
+*/
+
+package server
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func decode(t *testing.T, s string) any {
+	t.Helper()
+	var v any
+	if err := json.Unmarshal([]byte(s), &v); err != nil {
+		t.Fatalf("json.Unmarshal(%q) failed: %v", s, err)
+	}
+	return v
+}
+
+func TestRedactor(t *testing.T) {
+	key := []byte("key")
+	mac := hmac.New(sha256.New, key)
+	_, _ = mac.Write([]byte("hunter2"))
+	hashed := "hmac-sha256:" + hex.EncodeToString(mac.Sum(nil))
+
+	tests := []struct {
+		name    string
+		opts    []RedactorOption
+		payload string
+		want    string
+	}{
+		{
+			name: "Defaults",
+			payload: `{
+				"credentials": {"creds": {"credentialData": {"data": {"password": "aHVudGVyMg=="}}}},
+				"observed": {
+					"composite": {"resource": {"kind": "XDatabase"}, "connectionDetails": {"url": "cG9zdGdyZXM="}},
+					"resources": {
+						"db": {"resource": {"kind": "Instance"}, "connectionDetails": {"password": "aHVudGVyMg=="}},
+						"secret": {"resource": {"apiVersion": "v1", "kind": "Secret", "data": {"password": "aHVudGVyMg=="}}},
+						"configmap": {"resource": {"apiVersion": "v1", "kind": "ConfigMap", "data": {"key": "value"}}}
+					}
+				},
+				"desired": {
+					"composite": {"connectionDetails": {"url": "cG9zdGdyZXM="}},
+					"resources": {
+						"db": {"resource": {"kind": "Instance"}, "connectionDetails": {"password": "aHVudGVyMg=="}},
+						"secret": {"resource": {"apiVersion": "v1", "kind": "Secret", "stringData": {"password": "hunter2"}}}
+					}
+				},
+				"requiredResources": {"secrets": {"items": [{"resource": {"apiVersion": "v1", "kind": "Secret", "data": {"token": "dG9rZW4="}}}]}}
+			}`,
+			want: `{
+				"credentials": {"creds": {"credentialData": {"data": {"password": "<redacted>"}}}},
+				"observed": {
+					"composite": {"resource": {"kind": "XDatabase"}, "connectionDetails": {"url": "<redacted>"}},
+					"resources": {
+						"db": {"resource": {"kind": "Instance"}, "connectionDetails": {"password": "<redacted>"}},
+						"secret": {"resource": {"apiVersion": "v1", "kind": "Secret", "data": {"password": "<redacted>"}}},
+						"configmap": {"resource": {"apiVersion": "v1", "kind": "ConfigMap", "data": {"key": "value"}}}
+					}
+				},
+				"desired": {
+					"composite": {"connectionDetails": {"url": "<redacted>"}},
+					"resources": {
+						"db": {"resource": {"kind": "Instance"}, "connectionDetails": {"password": "<redacted>"}},
+						"secret": {"resource": {"apiVersion": "v1", "kind": "Secret", "stringData": {"password": "<redacted>"}}}
+					}
+				},
+				"requiredResources": {"secrets": {"items": [{"resource": {"apiVersion": "v1", "kind": "Secret", "data": {"token": "<redacted>"}}}]}}
+			}`,
+		},
+		{
+			name: "WithoutDefaults",
+			opts: []RedactorOption{WithoutDefaultRedactions()},
+			payload: `{
+				"credentials": {"creds": {"credentialData": {"data": {"password": "aHVudGVyMg=="}}}},
+				"desired": {"resources": {"secret": {"resource": {"apiVersion": "v1", "kind": "Secret", "stringData": {"password": "hunter2"}}}}}
+			}`,
+			want: `{
+				"credentials": {"creds": {"credentialData": {"data": {"password": "aHVudGVyMg=="}}}},
+				"desired": {"resources": {"secret": {"resource": {"apiVersion": "v1", "kind": "Secret", "stringData": {"password": "hunter2"}}}}}
+			}`,
+		},
+		{
+			name: "Rules",
+			opts: []RedactorOption{
+				WithoutDefaultRedactions(),
+				WithHMACKey(key),
+				WithRedactionRules(
+					RedactionRule{Action: RedactHash, Path: "$.observed.composite.resource.spec.password"},
+					RedactionRule{Action: RedactMask, Path: "desired.resources[*].resource.metadata.annotations['example.org/token']"},
+					RedactionRule{Action: RedactDrop, Path: "context"},
+					RedactionRule{Action: RedactDrop, Path: "input.items[1]"},
+					RedactionRule{Action: RedactMask, Path: "does.not.exist"},
+				),
+			},
+			payload: `{
+				"context": {"key": "value"},
+				"input": {"items": ["a", "b", "c"]},
+				"observed": {"composite": {"resource": {"spec": {"password": "hunter2"}}}},
+				"desired": {"resources": {"a": {"resource": {"metadata": {"annotations": {"example.org/token": "secret", "other": "value"}}}}}}
+			}`,
+			want: `{
+				"input": {"items": ["a", "c"]},
+				"observed": {"composite": {"resource": {"spec": {"password": "` + hashed + `"}}}},
+				"desired": {"resources": {"a": {"resource": {"metadata": {"annotations": {"example.org/token": "<redacted>", "other": "value"}}}}}}
+			}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := NewRedactor(tt.opts...)
+			if err != nil {
+				t.Fatalf("NewRedactor() failed: %v", err)
+			}
+			got := decode(t, tt.payload)
+			r.Redact(got)
+			if diff := cmp.Diff(decode(t, tt.want), got); diff != "" {
+				t.Errorf("Redact() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestParseRedactionRule(t *testing.T) {
+	tests := []struct {
+		rule    string
+		want    RedactionRule
+		wantErr bool
+	}{
+		{rule: "mask:spec.password", want: RedactionRule{Action: RedactMask, Path: "spec.password"}},
+		{rule: "HASH:$.spec['api.key']", want: RedactionRule{Action: RedactHash, Path: "$.spec['api.key']"}},
+		{rule: "drop:context", want: RedactionRule{Action: RedactDrop, Path: "context"}},
+		{rule: "spec.password", wantErr: true},
+		{rule: "encrypt:spec.password", wantErr: true},
+		{rule: "mask:", wantErr: true},
+		{rule: "mask:spec..password", wantErr: true},
+		{rule: "mask:spec[password", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.rule, func(t *testing.T) {
+			got, err := ParseRedactionRule(tt.rule)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("ParseRedactionRule(%q): expected error, got %+v", tt.rule, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseRedactionRule(%q) failed: %v", tt.rule, err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("ParseRedactionRule(%q) mismatch (-want +got):\n%s", tt.rule, diff)
+			}
+		})
+	}
+}
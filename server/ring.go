@@ -0,0 +1,118 @@
+/*
+Copyright 2026 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+this file except in compliance with the License. You may obtain a copy of the
+License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed
+under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+CONDITIONS OF ANY KIND, either express or implied. See the License for the
+specific language governing permissions and limitations under the License.
+*/
+
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sync"
+)
+
+// A RingBuffer is a sink that keeps the most recent events in memory, bounded
+// by count and by size. An event's size is the size of its JSON encoding.
+type RingBuffer struct {
+	maxEvents int
+	maxBytes  int64
+
+	mu      sync.RWMutex
+	entries []ringEntry
+	head    int
+	len     int
+	bytes   int64
+}
+
+type ringEntry struct {
+	event *Event
+	size  int64
+}
+
+// NewRingBuffer returns a sink that keeps up to the supplied number of events
+// in memory, using no more than the supplied number of bytes. Zero bytes means
+// the size is bounded only by the number of events.
+func NewRingBuffer(maxEvents int, maxBytes int64) *RingBuffer {
+	return &RingBuffer{
+		maxEvents: maxEvents,
+		maxBytes:  maxBytes,
+		entries:   make([]ringEntry, max(maxEvents, 1)),
+	}
+}
+
+// Write adds the supplied event to the buffer, evicting the oldest events to
+// make room if necessary. Events larger than the buffer aren't kept.
+func (r *RingBuffer) Write(e *Event) error {
+	j, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("cannot marshal event: %w", err)
+	}
+	size := int64(len(j))
+	if r.maxEvents <= 0 || (r.maxBytes > 0 && size > r.maxBytes) {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for r.len > 0 && (r.len >= r.maxEvents || (r.maxBytes > 0 && r.bytes+size > r.maxBytes)) {
+		r.evict()
+	}
+	r.entries[(r.head+r.len)%len(r.entries)] = ringEntry{event: e, size: size}
+	r.len++
+	r.bytes += size
+	return nil
+}
+
+// evict removes the oldest event. It must be called with the lock held.
+func (r *RingBuffer) evict() {
+	r.bytes -= r.entries[r.head].size
+	r.entries[r.head] = ringEntry{}
+	r.head = (r.head + 1) % len(r.entries)
+	r.len--
+}
+
+// List returns the events matching the supplied query, oldest first. Limited
+// queries return the newest matching events.
+func (r *RingBuffer) List(q Query) []*Event {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var events []*Event
+	for i := r.len - 1; i >= 0; i-- {
+		if q.Limit > 0 && len(events) == q.Limit {
+			break
+		}
+		e := r.entries[(r.head+i)%len(r.entries)].event
+		if q.Match(e) {
+			events = append(events, e)
+		}
+	}
+	slices.Reverse(events)
+	return events
+}
+
+// Len returns the number of events in the buffer.
+func (r *RingBuffer) Len() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.len
+}
+
+// Bytes returns the combined size of the events in the buffer.
+func (r *RingBuffer) Bytes() int64 {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.bytes
+}
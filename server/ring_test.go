@@ -0,0 +1,95 @@
+/*
+Copyright 2026 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+this file except in compliance with the License. You may obtain a copy of the
+License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed
+under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+CONDITIONS OF ANY KIND, either express or implied. See the License for the
+specific language governing permissions and limitations under the License.
+*/
+
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	pipelinev1alpha1 "github.com/crossplane/crossplane-runtime/v2/apis/pipelineinspector/proto/v1alpha1"
+)
+
+func spanEvent(span string, payload any) *Event {
+	return &Event{Type: EventTypeRequest, Meta: &pipelinev1alpha1.StepMeta{SpanId: span}, Payload: payload}
+}
+
+func spans(events []*Event) []string {
+	out := make([]string, 0, len(events))
+	for _, e := range events {
+		out = append(out, e.Meta.GetSpanId())
+	}
+	return out
+}
+
+func eventSize(t *testing.T, e *Event) int64 {
+	t.Helper()
+	j, err := json.Marshal(e)
+	if err != nil {
+		t.Fatalf("json.Marshal failed: %v", err)
+	}
+	return int64(len(j))
+}
+
+func TestRingBuffer_MaxEvents(t *testing.T) {
+	r := NewRingBuffer(3, 0)
+	for i := range 5 {
+		_ = r.Write(spanEvent(fmt.Sprintf("s%d", i), nil))
+	}
+
+	if diff := cmp.Diff([]string{"s2", "s3", "s4"}, spans(r.List(Query{}))); diff != "" {
+		t.Errorf("List() mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"s3", "s4"}, spans(r.List(Query{Limit: 2}))); diff != "" {
+		t.Errorf("List(limit) mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"s3"}, spans(r.List(Query{SpanID: "s3"}))); diff != "" {
+		t.Errorf("List(span) mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestRingBuffer_MaxBytes(t *testing.T) {
+	small := spanEvent("small", "x")
+	size := eventSize(t, small)
+
+	// Room for exactly three small events.
+	r := NewRingBuffer(100, 3*size)
+	for range 3 {
+		_ = r.Write(small)
+	}
+	if got := r.Len(); got != 3 {
+		t.Fatalf("Len(): want 3, got %d", got)
+	}
+
+	// A larger event evicts as many old events as needed to fit.
+	big := spanEvent("big", strings.Repeat("x", int(size)))
+	_ = r.Write(big)
+	if diff := cmp.Diff([]string{"small", "big"}, spans(r.List(Query{}))); diff != "" {
+		t.Errorf("List() mismatch (-want +got):\n%s", diff)
+	}
+	if got, want := r.Bytes(), size+eventSize(t, big); got != want {
+		t.Errorf("Bytes(): want %d, got %d", want, got)
+	}
+
+	// An event larger than the buffer isn't kept.
+	_ = r.Write(spanEvent("huge", strings.Repeat("x", int(4*size))))
+	if diff := cmp.Diff([]string{"small", "big"}, spans(r.List(Query{}))); diff != "" {
+		t.Errorf("List() mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,199 @@
+/*
+Copyright 2026 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+this file except in compliance with the License. You may obtain a copy of the
+License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed
+under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+CONDITIONS OF ANY KIND, either express or implied. See the License for the
+specific language governing permissions and limitations under the License.
+*/
+
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"math"
+	"sync"
+	"time"
+)
+
+// A SamplerOption configures a Sampler.
+type SamplerOption func(*Sampler)
+
+// WithSampleRatio sets the fraction of pipeline runs to keep, between 0 and 1
+// (default: 1).
+func WithSampleRatio(r float64) SamplerOption {
+	return func(s *Sampler) {
+		s.ratio = r
+	}
+}
+
+// WithRateLimit limits each composite resource or operation to the supplied
+// number of pipeline runs per second, with bursts of up to the supplied number
+// of runs. A rate of zero disables rate limiting (default: 0).
+func WithRateLimit(rate float64, burst int) SamplerOption {
+	return func(s *Sampler) {
+		s.rate = rate
+		s.burst = float64(max(burst, 1))
+	}
+}
+
+// WithKeepErrors sets whether events with an error are always kept, even if
+// their pipeline run was sampled out (default: true).
+func WithKeepErrors(keep bool) SamplerOption {
+	return func(s *Sampler) {
+		s.keepErrors = keep
+	}
+}
+
+// WithSamplingWindow sets how long the Sampler remembers whether it kept a
+// pipeline run, so later events of the run are kept or dropped consistently
+// (default: 10m).
+func WithSamplingWindow(d time.Duration) SamplerOption {
+	return func(s *Sampler) {
+		s.window = d
+	}
+}
+
+// A Sampler is a filter that keeps or drops whole pipeline runs, so that all
+// requests and responses of a run are kept together.
+//
+// Head sampling keeps a fixed fraction of runs, chosen by hashing their trace
+// ID. Every sampler makes the same decision for the same trace. Rate limiting
+// keeps up to a fixed number of runs per second for each composite resource or
+// operation, using a token bucket per UID. Runs are identified by trace ID and
+// UID, and the first event of a run takes a token.
+type Sampler struct {
+	ratio      float64
+	rate       float64
+	burst      float64
+	keepErrors bool
+	window     time.Duration
+	now        func() time.Time
+
+	mu        sync.Mutex
+	buckets   map[string]*tokenBucket
+	runs      map[pipelineKey]sampledRun
+	lastPrune time.Time
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+type sampledRun struct {
+	keep bool
+	seen time.Time
+}
+
+// NewSampler returns a filter that samples pipeline runs.
+func NewSampler(opts ...SamplerOption) *Sampler {
+	s := &Sampler{
+		ratio:      1,
+		burst:      1,
+		keepErrors: true,
+		window:     10 * time.Minute,
+		now:        time.Now,
+		buckets:    make(map[string]*tokenBucket),
+		runs:       make(map[pipelineKey]sampledRun),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Match returns true if the supplied event's pipeline run is sampled, or if
+// the event has an error and the Sampler keeps errors.
+func (s *Sampler) Match(e *Event) bool {
+	if s.keepErrors && e.Error != "" {
+		return true
+	}
+
+	traceID := e.Meta.GetTraceId()
+	if traceID == "" {
+		// Fall back to sampling each function call.
+		traceID = e.Meta.GetSpanId()
+	}
+	if !headSample(traceID, s.ratio) {
+		return false
+	}
+	if s.rate <= 0 {
+		return true
+	}
+
+	uid := contextUID(e.Meta)
+	k := pipelineKey{traceID: traceID, contextUID: uid}
+	now := s.now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.prune(now)
+	if r, ok := s.runs[k]; ok {
+		r.seen = now
+		s.runs[k] = r
+		return r.keep
+	}
+
+	keep := s.take(uid, now)
+	s.runs[k] = sampledRun{keep: keep, seen: now}
+	return keep
+}
+
+// headSample returns true if the supplied trace ID hashes into the kept
+// fraction of traces.
+func headSample(traceID string, ratio float64) bool {
+	switch {
+	case ratio >= 1:
+		return true
+	case ratio <= 0:
+		return false
+	}
+	h := sha256.Sum256([]byte(traceID))
+	return float64(binary.BigEndian.Uint64(h[:8])) < ratio*math.MaxUint64
+}
+
+// take returns true if a token could be taken from the supplied UID's bucket.
+// It must be called with the lock held.
+func (s *Sampler) take(uid string, now time.Time) bool {
+	b, ok := s.buckets[uid]
+	if !ok {
+		b = &tokenBucket{tokens: s.burst, last: now}
+		s.buckets[uid] = b
+	}
+	b.tokens = min(s.burst, b.tokens+now.Sub(b.last).Seconds()*s.rate)
+	b.last = now
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// prune forgets runs that haven't been seen for the sampling window, and
+// buckets that have refilled. It runs at most once per window. It must be
+// called with the lock held.
+func (s *Sampler) prune(now time.Time) {
+	if now.Sub(s.lastPrune) < s.window {
+		return
+	}
+	s.lastPrune = now
+	for k, r := range s.runs {
+		if now.Sub(r.seen) >= s.window {
+			delete(s.runs, k)
+		}
+	}
+	for uid, b := range s.buckets {
+		if b.tokens+now.Sub(b.last).Seconds()*s.rate >= s.burst {
+			delete(s.buckets, uid)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+/*
+Copyright 2026 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+this file except in compliance with the License. You may obtain a copy of the
+License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed
+under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+CONDITIONS OF ANY KIND, either express or implied. See the License for the
+specific language governing permissions and limitations under the License.
+*/
+
+package server
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	pipelinev1alpha1 "github.com/crossplane/crossplane-runtime/v2/apis/pipelineinspector/proto/v1alpha1"
+)
+
+func sampleEvent(typ, traceID, uid, errMsg string) *Event {
+	return &Event{
+		Type:  typ,
+		Error: errMsg,
+		Meta: &pipelinev1alpha1.StepMeta{
+			TraceId: traceID,
+			Context: &pipelinev1alpha1.StepMeta_CompositionMeta{
+				CompositionMeta: &pipelinev1alpha1.CompositionMeta{CompositeResourceUid: uid},
+			},
+		},
+	}
+}
+
+func TestSampler_Ratio(t *testing.T) {
+	s := NewSampler(WithSampleRatio(0.25), WithKeepErrors(false))
+
+	kept := 0
+	for i := range 10000 {
+		trace := fmt.Sprintf("trace-%d", i)
+		req := s.Match(sampleEvent(EventTypeRequest, trace, "uid", ""))
+		rsp := s.Match(sampleEvent(EventTypeResponse, trace, "uid", "boom"))
+		if req != rsp {
+			t.Fatalf("trace %s: request kept %t but response kept %t", trace, req, rsp)
+		}
+		if req {
+			kept++
+		}
+	}
+	if kept < 2300 || kept > 2700 {
+		t.Errorf("kept %d of 10000 traces, want about 2500", kept)
+	}
+
+	if NewSampler(WithSampleRatio(0)).Match(sampleEvent(EventTypeRequest, "trace", "uid", "")) {
+		t.Error("ratio 0: want event dropped")
+	}
+}
+
+func TestSampler_RateLimit(t *testing.T) {
+	now := time.Date(2026, 1, 15, 10, 30, 0, 0, time.UTC)
+	s := NewSampler(WithRateLimit(1, 2), WithSamplingWindow(time.Minute))
+	s.now = func() time.Time { return now }
+
+	run := func(trace, uid string) bool {
+		// Every event of a run gets the same decision.
+		req := s.Match(sampleEvent(EventTypeRequest, trace, uid, ""))
+		rsp := s.Match(sampleEvent(EventTypeResponse, trace, uid, ""))
+		if req != rsp {
+			t.Fatalf("run %s/%s: request kept %t but response kept %t", trace, uid, req, rsp)
+		}
+		return req
+	}
+
+	// A burst of two runs is kept, then the bucket is empty.
+	for i, want := range []bool{true, true, false} {
+		if got := run(fmt.Sprintf("trace-%d", i), "xr-a"); got != want {
+			t.Errorf("run %d: want kept %t, got %t", i, want, got)
+		}
+	}
+
+	// Buckets are per XR.
+	if !run("trace-3", "xr-b") {
+		t.Error("xr-b: want first run kept")
+	}
+
+	// Errors are kept even if their run was dropped.
+	if !s.Match(sampleEvent(EventTypeResponse, "trace-2", "xr-a", "boom")) {
+		t.Error("want error kept")
+	}
+
+	// The bucket refills at the configured rate.
+	now = now.Add(time.Second)
+	if !run("trace-4", "xr-a") {
+		t.Error("want run kept after refill")
+	}
+	if run("trace-5", "xr-a") {
+		t.Error("want run dropped after using refilled token")
+	}
+
+	// Decisions are forgotten after the sampling window.
+	now = now.Add(2 * time.Minute)
+	_ = s.Match(sampleEvent(EventTypeRequest, "trace-6", "xr-c", ""))
+	s.mu.Lock()
+	runs, buckets := len(s.runs), len(s.buckets)
+	s.mu.Unlock()
+	if runs != 1 || buckets != 1 {
+		t.Errorf("after window: want 1 run and 1 bucket, got %d runs and %d buckets", runs, buckets)
+	}
+}
@@ -19,37 +19,65 @@ package server
 import (
 	"context"
 	"encoding/json"
-	"fmt"
 	"io"
 	"os"
-	"strings"
 
-	"google.golang.org/protobuf/encoding/protojson"
-	"sigs.k8s.io/yaml"
+	"google.golang.org/protobuf/reflect/protoregistry"
 
 	pipelinev1alpha1 "github.com/crossplane/crossplane-runtime/v2/apis/pipelineinspector/proto/v1alpha1"
 	"github.com/crossplane/crossplane-runtime/v2/pkg/logging"
 )
 
-// Inspector implements the PipelineInspectorService by logging to a writer.
+// Inspector implements the PipelineInspectorService by writing captured events
+// to a Sink.
 type Inspector struct {
 	pipelinev1alpha1.UnimplementedPipelineInspectorServiceServer
 
-	format string
-	out    io.Writer
-	log    logging.Logger
+	format  string
+	out     io.Writer
+	sink    Sink
+	filter  Filter
+	metrics *Metrics
+	log     logging.Logger
 }
 
 // Option configures an Inspector.
 type Option func(*Inspector)
 
-// WithOutput sets the output writer (default: os.Stdout).
+// WithOutput sets the output writer used by the built-in sink selected by the
+// Inspector's format (default: os.Stdout). It has no effect if WithSink is
+// also supplied.
 func WithOutput(w io.Writer) Option {
 	return func(i *Inspector) {
 		i.out = w
 	}
 }
 
+// WithSink sets the sink that captured events are written to. It overrides the
+// built-in sink selected by the Inspector's format.
+func WithSink(s Sink) Option {
+	return func(i *Inspector) {
+		i.sink = s
+	}
+}
+
+// WithFilter sets a filter that captured events must match to be written. The
+// filter is applied before the payload is decoded, so events passed to it have
+// no payload.
+func WithFilter(f Filter) Option {
+	return func(i *Inspector) {
+		i.filter = f
+	}
+}
+
+// WithMetrics sets the Metrics the Inspector records captured events in.
+// Events are recorded before they're filtered.
+func WithMetrics(m *Metrics) Option {
+	return func(i *Inspector) {
+		i.metrics = m
+	}
+}
+
 // WithLogger sets the logger for the Inspector.
 func WithLogger(l logging.Logger) Option {
 	return func(i *Inspector) {
@@ -57,7 +85,9 @@ func WithLogger(l logging.Logger) Option {
 	}
 }
 
-// NewInspector creates a new Inspector with the given output format.
+// NewInspector creates a new Inspector with the given output format. Unless a
+// sink is supplied using WithSink the Inspector writes events in the given
+// format to its output writer.
 func NewInspector(format string, opts ...Option) *Inspector {
 	i := &Inspector{
 		format: format,
@@ -67,25 +97,52 @@ func NewInspector(format string, opts ...Option) *Inspector {
 	for _, opt := range opts {
 		opt(i)
 	}
+	if i.sink == nil {
+		i.sink = NewFormatSink(i.format, i.out)
+	}
 	return i
 }
 
 // EmitRequest logs the function request before execution.
 func (i *Inspector) EmitRequest(_ context.Context, req *pipelinev1alpha1.EmitRequestRequest) (*pipelinev1alpha1.EmitRequestResponse, error) {
-	// Decode JSON payload from bytes.
-	payload := decodeJSONPayload(req.GetRequest())
-	i.logEvent("REQUEST", req.GetMeta(), payload, "")
+	i.logEvent(EventTypeRequest, req.GetMeta(), req.GetRequest(), "")
 	return &pipelinev1alpha1.EmitRequestResponse{}, nil
 }
 
 // EmitResponse logs the function response after execution.
 func (i *Inspector) EmitResponse(_ context.Context, req *pipelinev1alpha1.EmitResponseRequest) (*pipelinev1alpha1.EmitResponseResponse, error) {
-	// Decode JSON payload from bytes.
-	payload := decodeJSONPayload(req.GetResponse())
-	i.logEvent("RESPONSE", req.GetMeta(), payload, req.GetError())
+	i.logEvent(EventTypeResponse, req.GetMeta(), req.GetResponse(), req.GetError())
 	return &pipelinev1alpha1.EmitResponseResponse{}, nil
 }
 
+// Sync the Inspector's sink to stable storage if it has a Sync method.
+func (i *Inspector) Sync() error {
+	return syncSink(i.sink)
+}
+
+// Close the Inspector's sink if it implements io.Closer.
+func (i *Inspector) Close() error {
+	return CloseSink(i.sink)
+}
+
+// A payloadDecoder is a sink that decodes the payloads of the events written
+// to it. The Inspector writes events to a payloadDecoder undecoded, so the
+// sink can decode them off the gRPC request path.
+type payloadDecoder interface {
+	decodesPayloads()
+}
+
+// A rawPayload is an undecoded JSON payload, written by the Inspector to a
+// payloadDecoder.
+type rawPayload []byte
+
+// decodePayload decodes the supplied event's payload, if it's undecoded.
+func decodePayload(e *Event) {
+	if raw, ok := e.Payload.(rawPayload); ok {
+		e.Payload = decodeMessagePayload(protoregistry.GlobalTypes, e.Type, raw)
+	}
+}
+
 // decodeJSONPayload decodes JSON bytes into a map for display.
 func decodeJSONPayload(data []byte) any {
 	if len(data) == 0 {
@@ -99,90 +156,29 @@ func decodeJSONPayload(data []byte) any {
 	return result
 }
 
-func (i *Inspector) logEvent(eventType string, meta *pipelinev1alpha1.StepMeta, payload any, errMsg string) {
-	if i.format == "text" {
-		i.logText(eventType, meta, payload, errMsg)
-		return
+func (i *Inspector) logEvent(eventType string, meta *pipelinev1alpha1.StepMeta, payload []byte, errMsg string) {
+	e := &Event{
+		Type:  eventType,
+		Meta:  meta,
+		Error: errMsg,
 	}
-	i.logJSON(eventType, meta, payload, errMsg)
-}
 
-func (i *Inspector) logJSON(eventType string, meta *pipelinev1alpha1.StepMeta, payload any, errMsg string) {
-	// Marshal meta using protojson to preserve proto field names.
-	metaJSON, err := protojson.Marshal(meta)
-	if err != nil {
-		i.log.Debug("Cannot marshal meta", "error", err)
-		return
+	if i.metrics != nil {
+		i.metrics.Observe(eventType, meta, len(payload), errMsg)
 	}
 
-	// Unmarshal meta into a map so we can include it in the final event.
-	var metaMap map[string]any
-	if err := json.Unmarshal(metaJSON, &metaMap); err != nil {
-		i.log.Debug("Cannot unmarshal meta", "error", err)
+	// Filter before decoding, to avoid work for events we won't write.
+	if i.filter != nil && !i.filter.Match(e) {
 		return
 	}
 
-	event := map[string]any{
-		"type":    eventType,
-		"meta":    metaMap,
-		"payload": payload,
-	}
-	if errMsg != "" {
-		event["error"] = errMsg
+	// Decode the payload from bytes, unless the sink will decode it.
+	if _, ok := i.sink.(payloadDecoder); ok {
+		e.Payload = rawPayload(payload)
+	} else {
+		e.Payload = decodeMessagePayload(protoregistry.GlobalTypes, eventType, payload)
 	}
-
-	eventJSON, err := json.Marshal(event)
-	if err != nil {
-		i.log.Debug("Cannot marshal event", "error", err)
-		return
-	}
-
-	_, _ = fmt.Fprintln(i.out, string(eventJSON))
-}
-
-func (i *Inspector) logText(eventType string, meta *pipelinev1alpha1.StepMeta, payload any, errMsg string) {
-	_, _ = fmt.Fprintf(i.out, "=== %s ===\n", eventType)
-
-	// Handle context-specific fields using type switch (idiomatic for oneofs).
-	switch ctx := meta.GetContext().(type) {
-	case *pipelinev1alpha1.StepMeta_CompositionMeta:
-		cm := ctx.CompositionMeta
-		_, _ = fmt.Fprintf(i.out, "  XR:          %s/%s (%s)\n", cm.GetCompositeResourceApiVersion(), cm.GetCompositeResourceKind(), cm.GetCompositeResourceName())
-		_, _ = fmt.Fprintf(i.out, "  XR UID:      %s\n", cm.GetCompositeResourceUid())
-		if ns := cm.GetCompositeResourceNamespace(); ns != "" {
-			_, _ = fmt.Fprintf(i.out, "  XR NS:       %s\n", ns)
-		}
-		_, _ = fmt.Fprintf(i.out, "  Composition: %s\n", cm.GetCompositionName())
-	case *pipelinev1alpha1.StepMeta_OperationMeta:
-		om := ctx.OperationMeta
-		_, _ = fmt.Fprintf(i.out, "  Operation:   %s\n", om.GetOperationName())
-		_, _ = fmt.Fprintf(i.out, "  Op UID:      %s\n", om.GetOperationUid())
-	}
-
-	_, _ = fmt.Fprintf(i.out, "  Step:        %s (index %d, iteration %d)\n", meta.GetStepName(), meta.GetStepIndex(), meta.GetIteration())
-	_, _ = fmt.Fprintf(i.out, "  Function:    %s\n", meta.GetFunctionName())
-	_, _ = fmt.Fprintf(i.out, "  Trace ID:    %s\n", meta.GetTraceId())
-	_, _ = fmt.Fprintf(i.out, "  Span ID:     %s\n", meta.GetSpanId())
-	_, _ = fmt.Fprintf(i.out, "  Timestamp:   %s\n", meta.GetTimestamp().AsTime().Format("2006-01-02T15:04:05.000Z07:00"))
-	if errMsg != "" {
-		_, _ = fmt.Fprintf(i.out, "  Error:       %s\n", errMsg)
-	}
-
-	// Pretty-print payload as YAML for readability.
-	if payload != nil {
-		payloadYAML, err := yaml.Marshal(payload)
-		if err == nil {
-			_, _ = fmt.Fprintf(i.out, "  Payload:\n%s\n", indentLines(string(payloadYAML), "    "))
-		}
-	}
-	_, _ = fmt.Fprintln(i.out)
-}
-
-// indentLines adds the given prefix to each line of the input string.
-func indentLines(s, prefix string) string {
-	var result strings.Builder
-	for line := range strings.SplitSeq(strings.TrimSuffix(s, "\n"), "\n") {
-		result.WriteString(prefix + line + "\n")
+	if err := i.sink.Write(e); err != nil {
+		i.log.Debug("Cannot write event", "type", eventType, "error", err)
 	}
-	return result.String()
 }
@@ -24,6 +24,8 @@ import (
 	"time"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"google.golang.org/protobuf/testing/protocmp"
 	"google.golang.org/protobuf/types/known/timestamppb"
 
 	pipelinev1alpha1 "github.com/crossplane/crossplane-runtime/v2/apis/pipelineinspector/proto/v1alpha1"
@@ -518,3 +520,80 @@ func TestNewInspector_WithOptions(t *testing.T) {
 		t.Error("expected output to be written to custom writer")
 	}
 }
+
+func TestInspector_WithFilter(t *testing.T) {
+	var got []*Event
+	sink := SinkFunc(func(e *Event) error {
+		got = append(got, e)
+		return nil
+	})
+	filter := FilterFunc(func(e *Event) bool {
+		if e.Payload != nil {
+			t.Error("filter called with a decoded payload")
+		}
+		return e.Meta.GetFunctionName() == "function-a"
+	})
+	inspector := NewInspector("json", WithSink(sink), WithFilter(filter))
+
+	for _, fn := range []string{"function-a", "function-b"} {
+		_, _ = inspector.EmitRequest(context.Background(), &pipelinev1alpha1.EmitRequestRequest{
+			Meta:    &pipelinev1alpha1.StepMeta{FunctionName: fn},
+			Request: []byte(`{"key":"value"}`),
+		})
+	}
+
+	want := []*Event{{
+		Type:    EventTypeRequest,
+		Meta:    &pipelinev1alpha1.StepMeta{FunctionName: "function-a"},
+		Payload: map[string]any{"key": "value"},
+	}}
+	if diff := cmp.Diff(want, got, protocmp.Transform()); diff != "" {
+		t.Errorf("written events mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestInspector_WithMetrics(t *testing.T) {
+	m := NewMetrics()
+	inspector := NewInspector("json",
+		WithSink(SinkFunc(func(*Event) error { return nil })),
+		WithFilter(FilterFunc(func(*Event) bool { return false })),
+		WithMetrics(m),
+	)
+
+	_, _ = inspector.EmitRequest(context.Background(), &pipelinev1alpha1.EmitRequestRequest{
+		Meta:    &pipelinev1alpha1.StepMeta{FunctionName: "function-a"},
+		Request: []byte(`{"key":"value"}`),
+	})
+
+	// Events are counted even though the filter drops them.
+	if got := testutil.ToFloat64(m.events.WithLabelValues(EventTypeRequest, "function-a", "", "")); got != 1 {
+		t.Errorf("events counted: want 1, got %v", got)
+	}
+}
+
+func TestInspector_WithQueueSink(t *testing.T) {
+	var got []*Event
+	q := NewQueueSink(SinkFunc(func(e *Event) error {
+		got = append(got, e)
+		return nil
+	}))
+	inspector := NewInspector("json", WithSink(q))
+
+	_, _ = inspector.EmitRequest(context.Background(), &pipelinev1alpha1.EmitRequestRequest{
+		Meta:    &pipelinev1alpha1.StepMeta{FunctionName: "function-a"},
+		Request: []byte(`{"key":"value"}`),
+	})
+	if err := q.Drain(context.Background()); err != nil {
+		t.Fatalf("Drain(...): %v", err)
+	}
+
+	// The queue's workers decode the payload, not the Inspector.
+	want := []*Event{{
+		Type:    EventTypeRequest,
+		Meta:    &pipelinev1alpha1.StepMeta{FunctionName: "function-a"},
+		Payload: map[string]any{"key": "value"},
+	}}
+	if diff := cmp.Diff(want, got, protocmp.Transform()); diff != "" {
+		t.Errorf("written events mismatch (-want +got):\n%s", diff)
+	}
+}
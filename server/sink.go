@@ -0,0 +1,287 @@
+/*
+Copyright 2026 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+this file except in compliance with the License. You may obtain a copy of the
+License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed
+under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+CONDITIONS OF ANY KIND, either express or implied. See the License for the
+specific language governing permissions and limitations under the License.
+*/
+
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"sigs.k8s.io/yaml"
+
+	pipelinev1alpha1 "github.com/crossplane/crossplane-runtime/v2/apis/pipelineinspector/proto/v1alpha1"
+)
+
+// Event types emitted by the Inspector.
+const (
+	EventTypeRequest  = "REQUEST"
+	EventTypeResponse = "RESPONSE"
+)
+
+// Output formats supported by the built-in sinks.
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+
+	// FormatSummary writes a compact summary of each event, rather than its
+	// full payload.
+	FormatSummary = "summary"
+)
+
+// An Event is a single function pipeline event captured by the Inspector.
+type Event struct {
+	// Type of the event, for example REQUEST or RESPONSE.
+	Type string
+
+	// Meta describes the pipeline step that produced the event.
+	Meta *pipelinev1alpha1.StepMeta
+
+	// Payload is the decoded RunFunctionRequest or RunFunctionResponse.
+	Payload any
+
+	// Error is the error returned by the function, if any.
+	Error string
+}
+
+// MarshalJSON marshals the event into a JSON object with type, meta, payload
+// and (if set) error fields. Meta is marshalled using protojson to preserve
+// proto field names.
+func (e *Event) MarshalJSON() ([]byte, error) {
+	metaJSON, err := protojson.Marshal(e.Meta)
+	if err != nil {
+		return nil, fmt.Errorf("cannot marshal meta: %w", err)
+	}
+
+	// Unmarshal meta into a map so we can include it in the final event.
+	var metaMap map[string]any
+	if err := json.Unmarshal(metaJSON, &metaMap); err != nil {
+		return nil, fmt.Errorf("cannot unmarshal meta: %w", err)
+	}
+
+	event := map[string]any{
+		"type":    e.Type,
+		"meta":    metaMap,
+		"payload": e.Payload,
+	}
+	if e.Error != "" {
+		event["error"] = e.Error
+	}
+
+	return json.Marshal(event)
+}
+
+// UnmarshalJSON unmarshals an event marshalled by MarshalJSON. The payload is
+// decoded into generic JSON types, regardless of the event's type.
+func (e *Event) UnmarshalJSON(data []byte) error {
+	var event struct {
+		Type    string          `json:"type"`
+		Meta    json.RawMessage `json:"meta"`
+		Payload any             `json:"payload"`
+		Error   string          `json:"error"`
+	}
+	if err := json.Unmarshal(data, &event); err != nil {
+		return err
+	}
+
+	meta := &pipelinev1alpha1.StepMeta{}
+	if len(event.Meta) > 0 && string(event.Meta) != "null" {
+		if err := (protojson.UnmarshalOptions{DiscardUnknown: true}).Unmarshal(event.Meta, meta); err != nil {
+			return fmt.Errorf("cannot unmarshal meta: %w", err)
+		}
+	}
+
+	*e = Event{Type: event.Type, Meta: meta, Payload: event.Payload, Error: event.Error}
+	return nil
+}
+
+// A Sink receives events captured by the Inspector.
+type Sink interface {
+	// Write the supplied event to the sink.
+	Write(e *Event) error
+}
+
+// A SinkFunc is a function that satisfies the Sink interface.
+type SinkFunc func(e *Event) error
+
+// Write the supplied event by calling the function.
+func (fn SinkFunc) Write(e *Event) error {
+	return fn(e)
+}
+
+// NewFormatSink returns a built-in sink that writes events to the supplied
+// writer in the supplied format. Unknown formats fall back to JSON.
+func NewFormatSink(format string, w io.Writer) Sink {
+	switch format {
+	case FormatText:
+		return NewTextSink(w)
+	case FormatSummary:
+		return NewSummarySink(w)
+	}
+	return NewJSONSink(w)
+}
+
+// A SyncWriter serializes writes to an underlying writer, so that concurrent
+// writes never interleave. Share a SyncWriter between sinks that write to the
+// same destination, such as stdout.
+type SyncWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewSyncWriter returns a writer that serializes writes to the supplied
+// writer. It returns the supplied writer if it's already a SyncWriter.
+func NewSyncWriter(w io.Writer) *SyncWriter {
+	if sw, ok := w.(*SyncWriter); ok {
+		return sw
+	}
+	return &SyncWriter{w: w}
+}
+
+// Write the supplied bytes to the underlying writer while holding a lock.
+func (w *SyncWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.w.Write(p)
+}
+
+// A JSONSink writes each event to a writer as a single line of JSON.
+type JSONSink struct {
+	out io.Writer
+}
+
+// NewJSONSink returns a sink that writes JSON lines to the supplied writer.
+// Each event is written with a single call to a SyncWriter.
+func NewJSONSink(w io.Writer) *JSONSink {
+	return &JSONSink{out: NewSyncWriter(w)}
+}
+
+// Write the supplied event as a line of JSON.
+func (s *JSONSink) Write(e *Event) error {
+	eventJSON, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("cannot marshal event: %w", err)
+	}
+
+	_, err = s.out.Write(append(eventJSON, '\n'))
+	return err
+}
+
+// A TextSink writes each event to a writer in a human-readable format.
+type TextSink struct {
+	out io.Writer
+}
+
+// NewTextSink returns a sink that writes human-readable text to the supplied
+// writer. Each event is rendered to a buffer and written with a single call to
+// a SyncWriter, so events written concurrently never interleave.
+func NewTextSink(w io.Writer) *TextSink {
+	return &TextSink{out: NewSyncWriter(w)}
+}
+
+// Write the supplied event as human-readable text.
+func (s *TextSink) Write(e *Event) error {
+	buf := &bytes.Buffer{}
+	renderText(buf, e)
+	_, err := s.out.Write(buf.Bytes())
+	return err
+}
+
+// renderText renders the supplied event as human-readable text.
+func renderText(buf *bytes.Buffer, e *Event) {
+	meta := e.Meta
+	_, _ = fmt.Fprintf(buf, "=== %s ===\n", e.Type)
+	renderContext(buf, meta)
+	_, _ = fmt.Fprintf(buf, "  Step:        %s (index %d, iteration %d)\n", meta.GetStepName(), meta.GetStepIndex(), meta.GetIteration())
+	_, _ = fmt.Fprintf(buf, "  Function:    %s\n", meta.GetFunctionName())
+	_, _ = fmt.Fprintf(buf, "  Trace ID:    %s\n", meta.GetTraceId())
+	_, _ = fmt.Fprintf(buf, "  Span ID:     %s\n", meta.GetSpanId())
+	_, _ = fmt.Fprintf(buf, "  Timestamp:   %s\n", meta.GetTimestamp().AsTime().Format("2006-01-02T15:04:05.000Z07:00"))
+	if e.Error != "" {
+		_, _ = fmt.Fprintf(buf, "  Error:       %s\n", e.Error)
+	}
+
+	// Render diffs as unified diffs, rather than as JSON Patches.
+	payload, diff := splitDiff(e.Payload)
+
+	// Pretty-print payload as YAML for readability.
+	if payload != nil {
+		payloadYAML, err := yaml.Marshal(payload)
+		if err == nil {
+			_, _ = fmt.Fprintf(buf, "  Payload:\n%s\n", indentLines(string(payloadYAML), "    "))
+		}
+	}
+	if diff != nil {
+		if u := diff.Unified(); u != "" {
+			_, _ = fmt.Fprintf(buf, "  Diff:\n%s\n", indentLines(u, "    "))
+		} else {
+			buf.WriteString("  Diff:        desired state unchanged\n")
+		}
+	}
+	buf.WriteString("\n")
+}
+
+// renderContext renders the composite resource or operation the supplied meta
+// belongs to.
+func renderContext(buf *bytes.Buffer, meta *pipelinev1alpha1.StepMeta) {
+	// Handle context-specific fields using type switch (idiomatic for oneofs).
+	switch ctx := meta.GetContext().(type) {
+	case *pipelinev1alpha1.StepMeta_CompositionMeta:
+		cm := ctx.CompositionMeta
+		_, _ = fmt.Fprintf(buf, "  XR:          %s/%s (%s)\n", cm.GetCompositeResourceApiVersion(), cm.GetCompositeResourceKind(), cm.GetCompositeResourceName())
+		_, _ = fmt.Fprintf(buf, "  XR UID:      %s\n", cm.GetCompositeResourceUid())
+		if ns := cm.GetCompositeResourceNamespace(); ns != "" {
+			_, _ = fmt.Fprintf(buf, "  XR NS:       %s\n", ns)
+		}
+		_, _ = fmt.Fprintf(buf, "  Composition: %s\n", cm.GetCompositionName())
+	case *pipelinev1alpha1.StepMeta_OperationMeta:
+		om := ctx.OperationMeta
+		_, _ = fmt.Fprintf(buf, "  Operation:   %s\n", om.GetOperationName())
+		_, _ = fmt.Fprintf(buf, "  Op UID:      %s\n", om.GetOperationUid())
+	}
+}
+
+// splitDiff returns the supplied payload without its diff, and its diff, if it
+// has one.
+func splitDiff(payload any) (any, *StepDiff) {
+	switch p := payload.(type) {
+	case *Step:
+		if p.Diff != nil {
+			c := *p
+			c.Diff = nil
+			return &c, p.Diff
+		}
+	case *Drift:
+		if p.Diff != nil {
+			c := *p
+			c.Diff = nil
+			return &c, p.Diff
+		}
+	}
+	return payload, nil
+}
+
+// indentLines adds the given prefix to each line of the input string.
+func indentLines(s, prefix string) string {
+	var result strings.Builder
+	for line := range strings.SplitSeq(strings.TrimSuffix(s, "\n"), "\n") {
+		result.WriteString(prefix + line + "\n")
+	}
+	return result.String()
+}
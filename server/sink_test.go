@@ -0,0 +1,239 @@
+/*
+Copyright 2026 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+this file except in compliance with the License. You may obtain a copy of the
+License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed
+under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+CONDITIONS OF ANY KIND, either express or implied. See the License for the
+specific language governing permissions and limitations under the License.
+*/
+
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/protobuf/testing/protocmp"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pipelinev1alpha1 "github.com/crossplane/crossplane-runtime/v2/apis/pipelineinspector/proto/v1alpha1"
+)
+
+func TestWithSink(t *testing.T) {
+	var got []*Event
+	var out bytes.Buffer
+	sink := SinkFunc(func(e *Event) error {
+		got = append(got, e)
+		return nil
+	})
+	inspector := NewInspector("json", WithOutput(&out), WithSink(sink))
+
+	meta := &pipelinev1alpha1.StepMeta{
+		FunctionName: "my-function",
+		Timestamp:    timestamppb.New(time.Date(2026, 1, 15, 10, 30, 0, 0, time.UTC)),
+	}
+
+	_, _ = inspector.EmitRequest(context.Background(), &pipelinev1alpha1.EmitRequestRequest{
+		Request: []byte(`{"key":"value"}`),
+		Meta:    meta,
+	})
+	_, _ = inspector.EmitResponse(context.Background(), &pipelinev1alpha1.EmitResponseRequest{
+		Error: "boom",
+		Meta:  meta,
+	})
+
+	want := []*Event{
+		{Type: EventTypeRequest, Meta: meta, Payload: map[string]any{"key": "value"}},
+		{Type: EventTypeResponse, Meta: meta, Error: "boom"},
+	}
+	if diff := cmp.Diff(want, got, protocmp.Transform()); diff != "" {
+		t.Errorf("events mismatch (-want +got):\n%s", diff)
+	}
+
+	if out.Len() != 0 {
+		t.Errorf("expected nothing written to output when a sink is supplied, got: %s", out.String())
+	}
+}
+
+func TestWithSink_Error(t *testing.T) {
+	sink := SinkFunc(func(_ *Event) error {
+		return errors.New("boom")
+	})
+	inspector := NewInspector("json", WithSink(sink))
+
+	// Sink errors must not fail the RPC.
+	_, err := inspector.EmitRequest(context.Background(), &pipelinev1alpha1.EmitRequestRequest{
+		Meta: &pipelinev1alpha1.StepMeta{},
+	})
+	if err != nil {
+		t.Errorf("EmitRequest returned error: %v", err)
+	}
+}
+
+func TestNewFormatSink(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		want   string
+	}{
+		{
+			name:   "json",
+			format: FormatJSON,
+			want:   "*server.JSONSink",
+		},
+		{
+			name:   "text",
+			format: FormatText,
+			want:   "*server.TextSink",
+		},
+		{
+			name:   "summary",
+			format: FormatSummary,
+			want:   "*server.SummarySink",
+		},
+		{
+			name:   "unknown falls back to json",
+			format: "yaml",
+			want:   "*server.JSONSink",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := fmt.Sprintf("%T", NewFormatSink(tt.format, nil))
+			if got != tt.want {
+				t.Errorf("NewFormatSink(%q): want %s, got %s", tt.format, tt.want, got)
+			}
+		})
+	}
+}
+
+func TestEventMarshalJSON(t *testing.T) {
+	e := &Event{
+		Type: EventTypeResponse,
+		Meta: &pipelinev1alpha1.StepMeta{
+			FunctionName: "my-function",
+			StepIndex:    2,
+		},
+		Payload: map[string]any{"desired": map[string]any{}},
+		Error:   "boom",
+	}
+
+	got, err := json.Marshal(e)
+	if err != nil {
+		t.Fatalf("json.Marshal failed: %v", err)
+	}
+
+	want := `{"error":"boom","meta":{"functionName":"my-function","stepIndex":2},"payload":{"desired":{}},"type":"RESPONSE"}`
+	if diff := cmp.Diff(want, string(got)); diff != "" {
+		t.Errorf("MarshalJSON mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestEventUnmarshalJSON(t *testing.T) {
+	want := &Event{
+		Type: EventTypeResponse,
+		Meta: &pipelinev1alpha1.StepMeta{
+			FunctionName: "my-function",
+			StepIndex:    2,
+			Timestamp:    timestamppb.New(time.Date(2026, 1, 15, 10, 30, 0, 0, time.UTC)),
+			Context: &pipelinev1alpha1.StepMeta_OperationMeta{
+				OperationMeta: &pipelinev1alpha1.OperationMeta{OperationName: "my-op"},
+			},
+		},
+		Payload: map[string]any{"desired": map[string]any{}},
+		Error:   "boom",
+	}
+
+	j, err := json.Marshal(want)
+	if err != nil {
+		t.Fatalf("json.Marshal failed: %v", err)
+	}
+	got := &Event{}
+	if err := json.Unmarshal(j, got); err != nil {
+		t.Fatalf("json.Unmarshal failed: %v", err)
+	}
+
+	if diff := cmp.Diff(want, got, protocmp.Transform()); diff != "" {
+		t.Errorf("UnmarshalJSON mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestTextSink_Concurrent(t *testing.T) {
+	const emits = 500
+
+	var buf bytes.Buffer
+	inspector := NewInspector("text", WithOutput(&buf))
+
+	var wg sync.WaitGroup
+	for n := range emits {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			meta := &pipelinev1alpha1.StepMeta{
+				StepName:     fmt.Sprintf("step-%d", n),
+				FunctionName: fmt.Sprintf("function-%d", n),
+				TraceId:      fmt.Sprintf("trace-%d", n),
+				Timestamp:    timestamppb.New(time.Date(2026, 1, 15, 10, 30, 0, 0, time.UTC)),
+				Context: &pipelinev1alpha1.StepMeta_CompositionMeta{
+					CompositionMeta: &pipelinev1alpha1.CompositionMeta{
+						CompositeResourceApiVersion: "example.org/v1",
+						CompositeResourceKind:       "XDatabase",
+						CompositeResourceName:       fmt.Sprintf("xr-%d", n),
+					},
+				},
+			}
+			_, _ = inspector.EmitRequest(context.Background(), &pipelinev1alpha1.EmitRequestRequest{
+				Request: fmt.Appendf(nil, `{"index":%d}`, n),
+				Meta:    meta,
+			})
+		}()
+	}
+	wg.Wait()
+
+	blocks := strings.Split(strings.TrimPrefix(buf.String(), "=== REQUEST ===\n"), "=== REQUEST ===\n")
+	if len(blocks) != emits {
+		t.Fatalf("expected %d blocks, got %d", emits, len(blocks))
+	}
+
+	seen := make(map[string]bool, emits)
+	for _, b := range blocks {
+		// Every line of a block must belong to the same emit.
+		var n int
+		if _, err := fmt.Sscanf(b, "  XR:          example.org/v1/XDatabase (xr-%d)", &n); err != nil {
+			t.Fatalf("cannot parse block: %v\n%s", err, b)
+		}
+		want := fmt.Sprintf("  XR:          example.org/v1/XDatabase (xr-%[1]d)\n"+
+			"  XR UID:      \n"+
+			"  Composition: \n"+
+			"  Step:        step-%[1]d (index 0, iteration 0)\n"+
+			"  Function:    function-%[1]d\n"+
+			"  Trace ID:    trace-%[1]d\n"+
+			"  Span ID:     \n"+
+			"  Timestamp:   2026-01-15T10:30:00.000Z\n"+
+			"  Payload:\n"+
+			"    index: %[1]d\n"+
+			"\n\n", n)
+		if diff := cmp.Diff(want, b); diff != "" {
+			t.Errorf("block %d is not intact (-want +got):\n%s", n, diff)
+		}
+		seen[want] = true
+	}
+	if len(seen) != emits {
+		t.Errorf("expected %d distinct blocks, got %d", emits, len(seen))
+	}
+}
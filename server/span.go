@@ -0,0 +1,133 @@
+/*
+Copyright 2026 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+this file except in compliance with the License. You may obtain a copy of the
+License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed
+under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+CONDITIONS OF ANY KIND, either express or implied. See the License for the
+specific language governing permissions and limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// SpanTracerName is the name of the tracer that creates spans for function
+// calls.
+const SpanTracerName = "github.com/crossplane/inspector-sidecar/server"
+
+// A SpanSink exports each STEP event as an OpenTelemetry span. The span starts
+// when the function's request was sent, and ends when its response was
+// received. It's a child of the span Crossplane recorded in the step's trace
+// and span IDs, so it appears under that span in tracing backends.
+//
+// A span's status is an error if the function returned an error or a fatal
+// result. Other events are ignored, so a SpanSink needs paired steps. Wrap it
+// in a PairingSink if events might not be paired.
+type SpanSink struct {
+	tp     *sdktrace.TracerProvider
+	tracer trace.Tracer
+}
+
+// NewSpanSink returns a sink that exports spans using the supplied exporter.
+// Spans are exported in batches.
+func NewSpanSink(exp sdktrace.SpanExporter) *SpanSink {
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exp),
+		sdktrace.WithResource(otlpResource()),
+		// Events have already been sampled by the time they reach a sink.
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+	)
+	return &SpanSink{tp: tp, tracer: tp.Tracer(SpanTracerName)}
+}
+
+// Write a span for the supplied event if it's a STEP event.
+func (s *SpanSink) Write(e *Event) error {
+	step, ok := e.Payload.(*Step)
+	if !ok || e.Type != EventTypeStep {
+		return nil
+	}
+
+	ctx := context.Background()
+	if parent := parentSpanContext(e); parent.IsValid() {
+		ctx = trace.ContextWithRemoteSpanContext(ctx, parent)
+	}
+
+	start := time.Now()
+	if ts := e.Meta.GetTimestamp(); ts != nil {
+		start = ts.AsTime()
+	}
+
+	attrs := stepMetaAttributes(e.Meta)
+	if step.Incomplete {
+		attrs = append(attrs, attribute.Bool(AttrStepIncomplete, true))
+	}
+
+	_, span := s.tracer.Start(ctx, spanName(e),
+		trace.WithTimestamp(start),
+		trace.WithAttributes(attrs...),
+		trace.WithSpanKind(trace.SpanKindInternal),
+	)
+	if e.Error != "" {
+		span.SetStatus(codes.Error, e.Error)
+	} else if msg, ok := fatalResult(step.Response); ok {
+		span.SetStatus(codes.Error, msg)
+	}
+	span.End(trace.WithTimestamp(start.Add(step.Duration)))
+	return nil
+}
+
+// Sync exports all spans that haven't been exported yet.
+func (s *SpanSink) Sync() error {
+	return s.tp.ForceFlush(context.Background())
+}
+
+// Close exports all spans that haven't been exported yet, then shuts down the
+// exporter. Spans that can't be exported within otlpShutdownTimeout are
+// dropped.
+func (s *SpanSink) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), otlpShutdownTimeout)
+	defer cancel()
+	return s.tp.Shutdown(ctx)
+}
+
+// parentSpanContext returns the span context Crossplane recorded for the
+// supplied event, which is invalid if the event doesn't have valid IDs.
+func parentSpanContext(e *Event) trace.SpanContext {
+	traceID, err := trace.TraceIDFromHex(e.Meta.GetTraceId())
+	if err != nil {
+		return trace.SpanContext{}
+	}
+	spanID, err := trace.SpanIDFromHex(e.Meta.GetSpanId())
+	if err != nil {
+		return trace.SpanContext{}
+	}
+	return trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: trace.FlagsSampled,
+		Remote:     true,
+	})
+}
+
+// spanName returns the name of the span for the supplied event: its step name,
+// or its function name if the step is unnamed.
+func spanName(e *Event) string {
+	if n := e.Meta.GetStepName(); n != "" {
+		return n
+	}
+	return e.Meta.GetFunctionName()
+}
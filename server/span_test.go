@@ -0,0 +1,180 @@
+/*
+Copyright 2026 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+this file except in compliance with the License. You may obtain a copy of the
+License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed
+under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+CONDITIONS OF ANY KIND, either express or implied. See the License for the
+specific language governing permissions and limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"encoding/hex"
+	"net"
+	"sort"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	collectortracev1 "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	commonv1 "go.opentelemetry.io/proto/otlp/common/v1"
+	tracev1 "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pipelinev1alpha1 "github.com/crossplane/crossplane-runtime/v2/apis/pipelineinspector/proto/v1alpha1"
+)
+
+// A traceCollector is an in-process OTLP trace collector.
+type traceCollector struct {
+	collectortracev1.UnimplementedTraceServiceServer
+
+	mu    sync.Mutex
+	spans []*tracev1.Span
+}
+
+func (c *traceCollector) Export(_ context.Context, req *collectortracev1.ExportTraceServiceRequest) (*collectortracev1.ExportTraceServiceResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, rs := range req.GetResourceSpans() {
+		for _, ss := range rs.GetScopeSpans() {
+			c.spans = append(c.spans, ss.GetSpans()...)
+		}
+	}
+	return &collectortracev1.ExportTraceServiceResponse{}, nil
+}
+
+// startTraceCollector starts an in-process collector, and returns it and its
+// address.
+func startTraceCollector(t *testing.T) (*traceCollector, string) {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen(...): %v", err)
+	}
+	c := &traceCollector{}
+	srv := grpc.NewServer()
+	collectortracev1.RegisterTraceServiceServer(srv, c)
+	go func() { _ = srv.Serve(l) }()
+	t.Cleanup(srv.Stop)
+	return c, l.Addr().String()
+}
+
+func TestSpanSink(t *testing.T) {
+	collector, addr := startTraceCollector(t)
+	exp, err := NewOTLPSpanExporter(context.Background(), OTLPEndpoint{Endpoint: addr, Insecure: true})
+	if err != nil {
+		t.Fatalf("NewOTLPSpanExporter(...): %v", err)
+	}
+	s := NewPairingSink(NewSpanSink(exp))
+
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	meta := func(span, step string, ts time.Time) *pipelinev1alpha1.StepMeta {
+		return &pipelinev1alpha1.StepMeta{
+			TraceId:      "0102030405060708090a0b0c0d0e0f10",
+			SpanId:       span,
+			StepName:     step,
+			StepIndex:    1,
+			FunctionName: "function-patch",
+			Timestamp:    timestamppb.New(ts),
+			Context: &pipelinev1alpha1.StepMeta_CompositionMeta{CompositionMeta: &pipelinev1alpha1.CompositionMeta{
+				CompositeResourceApiVersion: "example.org/v1",
+				CompositeResourceKind:       "XDatabase",
+				CompositeResourceName:       "my-db",
+				CompositeResourceUid:        "uid-1",
+				CompositionName:             "my-composition",
+			}},
+		}
+	}
+	events := []*Event{
+		{Type: EventTypeRequest, Meta: meta("0102030405060708", "ok", start)},
+		{Type: EventTypeResponse, Meta: meta("0102030405060708", "ok", start.Add(2*time.Second)), Payload: map[string]any{}},
+		{Type: EventTypeRequest, Meta: meta("1112131415161718", "errored", start)},
+		{Type: EventTypeResponse, Meta: meta("1112131415161718", "errored", start.Add(time.Second)), Error: "connection refused"},
+		{Type: EventTypeRequest, Meta: meta("2122232425262728", "fatal", start)},
+		{Type: EventTypeResponse, Meta: meta("2122232425262728", "fatal", start.Add(time.Second)), Payload: map[string]any{
+			"results": []any{map[string]any{"severity": "SEVERITY_FATAL", "message": "invalid input"}},
+		}},
+	}
+	for _, e := range events {
+		if err := s.Write(e); err != nil {
+			t.Fatalf("Write(...): %v", err)
+		}
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close(): %v", err)
+	}
+
+	type span struct {
+		Name     string
+		TraceID  string
+		ParentID string
+		Duration time.Duration
+		Status   tracev1.Status_StatusCode
+		Message  string
+		Attrs    map[string]string
+	}
+	collector.mu.Lock()
+	got := make([]span, 0, len(collector.spans))
+	for _, sp := range collector.spans {
+		attrs := map[string]string{}
+		for _, kv := range sp.GetAttributes() {
+			attrs[kv.GetKey()] = attrString(kv.GetValue())
+		}
+		got = append(got, span{
+			Name:     sp.GetName(),
+			TraceID:  hex.EncodeToString(sp.GetTraceId()),
+			ParentID: hex.EncodeToString(sp.GetParentSpanId()),
+			Duration: time.Duration(sp.GetEndTimeUnixNano() - sp.GetStartTimeUnixNano()),
+			Status:   sp.GetStatus().GetCode(),
+			Message:  sp.GetStatus().GetMessage(),
+			Attrs:    attrs,
+		})
+	}
+	collector.mu.Unlock()
+	sort.Slice(got, func(i, j int) bool { return got[i].ParentID < got[j].ParentID })
+
+	attrs := func(step string) map[string]string {
+		return map[string]string{
+			AttrFunctionName:    "function-patch",
+			AttrStepName:        step,
+			AttrStepIndex:       "1",
+			AttrStepIteration:   "0",
+			AttrXRAPIVersion:    "example.org/v1",
+			AttrXRKind:          "XDatabase",
+			AttrXRName:          "my-db",
+			AttrXRUID:           "uid-1",
+			AttrCompositionName: "my-composition",
+		}
+	}
+	want := []span{
+		{Name: "ok", TraceID: "0102030405060708090a0b0c0d0e0f10", ParentID: "0102030405060708", Duration: 2 * time.Second, Attrs: attrs("ok")},
+		{Name: "errored", TraceID: "0102030405060708090a0b0c0d0e0f10", ParentID: "1112131415161718", Duration: time.Second, Status: tracev1.Status_STATUS_CODE_ERROR, Message: "connection refused", Attrs: attrs("errored")},
+		{Name: "fatal", TraceID: "0102030405060708090a0b0c0d0e0f10", ParentID: "2122232425262728", Duration: time.Second, Status: tracev1.Status_STATUS_CODE_ERROR, Message: "invalid input", Attrs: attrs("fatal")},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("exported spans mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func attrString(v *commonv1.AnyValue) string {
+	switch x := v.GetValue().(type) {
+	case *commonv1.AnyValue_StringValue:
+		return x.StringValue
+	case *commonv1.AnyValue_IntValue:
+		return strconv.FormatInt(x.IntValue, 10)
+	case *commonv1.AnyValue_BoolValue:
+		return strconv.FormatBool(x.BoolValue)
+	}
+	return ""
+}
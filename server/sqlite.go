@@ -0,0 +1,381 @@
+/*
+Copyright 2026 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+this file except in compliance with the License. You may obtain a copy of the
+License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed
+under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+CONDITIONS OF ANY KIND, either express or implied. See the License for the
+specific language governing permissions and limitations under the License.
+*/
+
+package server
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	_ "modernc.org/sqlite" // Registers the sqlite database/sql driver.
+)
+
+// SQLiteDriverName is the database/sql driver used to open SQLite databases.
+// It's registered by modernc.org/sqlite, which doesn't require cgo.
+const SQLiteDriverName = "sqlite"
+
+// DefaultSQLiteCompactInterval is how often a SQLiteSink enforces its
+// retention policy by default.
+const DefaultSQLiteCompactInterval = time.Minute
+
+// sqliteSchema creates the events table, and an index for each column except
+// the payload.
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS events (
+	id               INTEGER PRIMARY KEY AUTOINCREMENT,
+	type             TEXT    NOT NULL,
+	timestamp        INTEGER NOT NULL,
+	trace_id         TEXT    NOT NULL,
+	span_id          TEXT    NOT NULL,
+	step_index       INTEGER NOT NULL,
+	iteration        INTEGER NOT NULL,
+	step_name        TEXT    NOT NULL,
+	function_name    TEXT    NOT NULL,
+	xr_api_version   TEXT    NOT NULL,
+	xr_kind          TEXT    NOT NULL,
+	xr_name          TEXT    NOT NULL,
+	xr_namespace     TEXT    NOT NULL,
+	xr_uid           TEXT    NOT NULL,
+	composition_name TEXT    NOT NULL,
+	operation_name   TEXT    NOT NULL,
+	operation_uid    TEXT    NOT NULL,
+	error            TEXT    NOT NULL,
+	payload          BLOB
+);
+CREATE INDEX IF NOT EXISTS events_type             ON events (type);
+CREATE INDEX IF NOT EXISTS events_timestamp        ON events (timestamp);
+CREATE INDEX IF NOT EXISTS events_trace_id         ON events (trace_id);
+CREATE INDEX IF NOT EXISTS events_span_id          ON events (span_id);
+CREATE INDEX IF NOT EXISTS events_step             ON events (step_index, iteration);
+CREATE INDEX IF NOT EXISTS events_step_name        ON events (step_name);
+CREATE INDEX IF NOT EXISTS events_function_name    ON events (function_name);
+CREATE INDEX IF NOT EXISTS events_xr_api_version   ON events (xr_api_version);
+CREATE INDEX IF NOT EXISTS events_xr_kind          ON events (xr_kind);
+CREATE INDEX IF NOT EXISTS events_xr_name          ON events (xr_name);
+CREATE INDEX IF NOT EXISTS events_xr_namespace     ON events (xr_namespace);
+CREATE INDEX IF NOT EXISTS events_xr_uid           ON events (xr_uid);
+CREATE INDEX IF NOT EXISTS events_composition_name ON events (composition_name);
+CREATE INDEX IF NOT EXISTS events_operation_name   ON events (operation_name);
+CREATE INDEX IF NOT EXISTS events_operation_uid    ON events (operation_uid);
+CREATE INDEX IF NOT EXISTS events_error            ON events (error);
+`
+
+// sqliteUsedSize returns the number of bytes used by the database's pages,
+// including rows and indexes but excluding free pages.
+const sqliteUsedSize = `
+SELECT (page_count - freelist_count) * page_size
+FROM pragma_page_count(), pragma_freelist_count(), pragma_page_size()`
+
+// sqliteDeleteOldest deletes up to the supplied number of the oldest events.
+const sqliteDeleteOldest = `DELETE FROM events WHERE id IN (SELECT id FROM events ORDER BY id LIMIT ?)`
+
+// sqliteInsert inserts an event. Its parameters are returned by sqliteRow.
+const sqliteInsert = `
+INSERT INTO events (
+	type, timestamp, trace_id, span_id, step_index, iteration, step_name, function_name,
+	xr_api_version, xr_kind, xr_name, xr_namespace, xr_uid, composition_name,
+	operation_name, operation_uid, error, payload
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+// A SQLiteOption configures a SQLiteSink.
+type SQLiteOption func(*SQLiteSink)
+
+// WithSQLiteRetention deletes events older than the supplied duration. Zero
+// keeps events regardless of age.
+func WithSQLiteRetention(d time.Duration) SQLiteOption {
+	return func(s *SQLiteSink) {
+		s.retention = d
+	}
+}
+
+// WithMaxSQLiteSize deletes the oldest events until the database's pages,
+// including rows and indexes, use no more than the supplied number of bytes.
+// The write-ahead log isn't included. Zero disables the size budget.
+func WithMaxSQLiteSize(bytes int64) SQLiteOption {
+	return func(s *SQLiteSink) {
+		s.maxSize = bytes
+	}
+}
+
+// WithSQLiteCompactInterval sets how often the retention policy is enforced.
+func WithSQLiteCompactInterval(d time.Duration) SQLiteOption {
+	return func(s *SQLiteSink) {
+		s.interval = d
+	}
+}
+
+// A SQLiteSink stores events in an embedded SQLite database, so they survive
+// restarts and can be queried with SQL. Each StepMeta field is stored in its
+// own indexed column, and payloads are stored as gzipped JSON.
+//
+// A background compactor enforces the sink's retention policy, deleting events
+// by age and by database size.
+type SQLiteSink struct {
+	db        *sql.DB
+	insert    *sql.Stmt
+	retention time.Duration
+	maxSize   int64
+	interval  time.Duration
+	now       func() time.Time
+
+	mu  sync.Mutex
+	err error
+
+	stop chan struct{}
+	done chan struct{}
+	once sync.Once
+}
+
+// NewSQLiteSink opens the supplied SQLite database, creating it and its
+// directory if necessary, and starts its compactor.
+func NewSQLiteSink(path string, opts ...SQLiteOption) (*SQLiteSink, error) {
+	s := &SQLiteSink{
+		interval: DefaultSQLiteCompactInterval,
+		now:      time.Now,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return nil, fmt.Errorf("cannot create directory: %w", err)
+	}
+	// WAL lets readers query the database while the sink writes to it.
+	// Incremental vacuum lets the compactor return space freed by deleted
+	// events to the filesystem. It only takes effect when the database is
+	// created.
+	db, err := sql.Open(SQLiteDriverName, "file:"+path+"?_pragma=auto_vacuum(incremental)&_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)")
+	if err != nil {
+		return nil, fmt.Errorf("cannot open database: %w", err)
+	}
+	// SQLite allows one writer at a time.
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("cannot create schema: %w", err)
+	}
+	insert, err := db.Prepare(sqliteInsert)
+	if err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("cannot prepare insert: %w", err)
+	}
+	s.db = db
+	s.insert = insert
+
+	go s.compactor()
+	return s, nil
+}
+
+// Write the supplied event to the database.
+func (s *SQLiteSink) Write(e *Event) error {
+	row, err := sqliteRow(e)
+	if err != nil {
+		return err
+	}
+	_, err = s.insert.Exec(row...)
+
+	s.mu.Lock()
+	s.err = err
+	s.mu.Unlock()
+
+	if err != nil {
+		return fmt.Errorf("cannot insert event: %w", err)
+	}
+	return nil
+}
+
+// Compact deletes events that fall outside the sink's retention policy, then
+// returns the space they used to the filesystem.
+func (s *SQLiteSink) Compact(ctx context.Context) error {
+	if s.retention > 0 {
+		cutoff := s.now().Add(-s.retention).UnixNano()
+		if _, err := s.db.ExecContext(ctx, "DELETE FROM events WHERE timestamp < ?", cutoff); err != nil {
+			return fmt.Errorf("cannot delete expired events: %w", err)
+		}
+	}
+	if s.maxSize > 0 {
+		if err := s.compactSize(ctx); err != nil {
+			return err
+		}
+	}
+	if _, err := s.db.ExecContext(ctx, "PRAGMA incremental_vacuum"); err != nil {
+		return fmt.Errorf("cannot vacuum database: %w", err)
+	}
+	return nil
+}
+
+// compactSize deletes the oldest events, a batch at a time, until the pages
+// the database uses fit in the sink's size budget. Deleted events' pages are
+// freed, so they don't count toward the budget even before they're vacuumed.
+// Each batch is the share of events that exceeds the budget, assuming events
+// are of similar size.
+func (s *SQLiteSink) compactSize(ctx context.Context) error {
+	for {
+		var used, count int64
+		if err := s.db.QueryRowContext(ctx, sqliteUsedSize).Scan(&used); err != nil {
+			return fmt.Errorf("cannot measure database size: %w", err)
+		}
+		if used <= s.maxSize {
+			return nil
+		}
+		if err := s.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM events").Scan(&count); err != nil {
+			return fmt.Errorf("cannot count events: %w", err)
+		}
+		batch := count*(used-s.maxSize)/used + 1
+		res, err := s.db.ExecContext(ctx, sqliteDeleteOldest, batch)
+		if err != nil {
+			return fmt.Errorf("cannot delete oldest events: %w", err)
+		}
+		// The schema alone may not fit in a tiny budget.
+		if n, err := res.RowsAffected(); err != nil || n == 0 {
+			return err
+		}
+	}
+}
+
+// Sync checkpoints the write-ahead log into the database file.
+func (s *SQLiteSink) Sync() error {
+	_, err := s.db.Exec("PRAGMA wal_checkpoint(TRUNCATE)")
+	return err
+}
+
+// Check returns an error if the database is unreachable, or if the last write
+// failed.
+func (s *SQLiteSink) Check() error {
+	if err := s.db.Ping(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return fmt.Errorf("last write failed: %w", s.err)
+	}
+	return nil
+}
+
+// Close stops the compactor and closes the database.
+func (s *SQLiteSink) Close() error {
+	s.once.Do(func() { close(s.stop) })
+	<-s.done
+	return errors.Join(s.insert.Close(), s.db.Close())
+}
+
+// compactor periodically enforces the retention policy until the sink is
+// closed.
+func (s *SQLiteSink) compactor() {
+	defer close(s.done)
+	if s.retention <= 0 && s.maxSize <= 0 {
+		<-s.stop
+		return
+	}
+
+	t := time.NewTicker(s.interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-t.C:
+			// Errors are transient, e.g. a busy database. The next
+			// compaction will retry.
+			_ = s.Compact(context.Background())
+		}
+	}
+}
+
+// sqliteRow returns the parameters of sqliteInsert for the supplied event.
+func sqliteRow(e *Event) ([]any, error) {
+	var payload []byte
+	if e.Payload != nil {
+		j, err := json.Marshal(e.Payload)
+		if err != nil {
+			return nil, fmt.Errorf("cannot marshal payload: %w", err)
+		}
+		if payload, err = gzipBytes(j); err != nil {
+			return nil, fmt.Errorf("cannot compress payload: %w", err)
+		}
+	}
+
+	m := e.Meta
+	var ts int64
+	if m.GetTimestamp() != nil {
+		ts = m.GetTimestamp().AsTime().UnixNano()
+	}
+	xr := m.GetCompositionMeta()
+	op := m.GetOperationMeta()
+	return []any{
+		e.Type,
+		ts,
+		m.GetTraceId(),
+		m.GetSpanId(),
+		m.GetStepIndex(),
+		m.GetIteration(),
+		m.GetStepName(),
+		m.GetFunctionName(),
+		xr.GetCompositeResourceApiVersion(),
+		xr.GetCompositeResourceKind(),
+		xr.GetCompositeResourceName(),
+		xr.GetCompositeResourceNamespace(),
+		xr.GetCompositeResourceUid(),
+		xr.GetCompositionName(),
+		op.GetOperationName(),
+		op.GetOperationUid(),
+		e.Error,
+		payload,
+	}, nil
+}
+
+// gzipBytes returns the supplied bytes, gzipped.
+func gzipBytes(b []byte) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	zw := gzip.NewWriter(buf)
+	if _, err := zw.Write(b); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// DecodeSQLitePayload decodes a payload stored by a SQLiteSink into generic
+// JSON values.
+func DecodeSQLitePayload(b []byte) (any, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = zr.Close() }()
+	j, err := io.ReadAll(zr)
+	if err != nil {
+		return nil, err
+	}
+	var v any
+	err = json.Unmarshal(j, &v)
+	return v, err
+}
@@ -0,0 +1,238 @@
+/*
+Copyright 2026 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+this file except in compliance with the License. You may obtain a copy of the
+License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed
+under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+CONDITIONS OF ANY KIND, either express or implied. See the License for the
+specific language governing permissions and limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"encoding/base64"
+	"math/rand/v2"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pipelinev1alpha1 "github.com/crossplane/crossplane-runtime/v2/apis/pipelineinspector/proto/v1alpha1"
+)
+
+func TestSQLiteRow(t *testing.T) {
+	ts := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	payload := map[string]any{"desired": map[string]any{"resources": map[string]any{"bucket": map[string]any{}}}}
+	e := &Event{
+		Type: EventTypeResponse,
+		Meta: &pipelinev1alpha1.StepMeta{
+			TraceId:      "trace",
+			SpanId:       "span",
+			StepIndex:    2,
+			Iteration:    1,
+			StepName:     "patch",
+			FunctionName: "function-patch",
+			Timestamp:    timestamppb.New(ts),
+			Context: &pipelinev1alpha1.StepMeta_CompositionMeta{CompositionMeta: &pipelinev1alpha1.CompositionMeta{
+				CompositeResourceApiVersion: "example.org/v1",
+				CompositeResourceKind:       "XBucket",
+				CompositeResourceName:       "my-bucket",
+				CompositeResourceNamespace:  "default",
+				CompositeResourceUid:        "uid-1",
+				CompositionName:             "buckets",
+			}},
+		},
+		Payload: payload,
+		Error:   "boom",
+	}
+
+	row, err := sqliteRow(e)
+	if err != nil {
+		t.Fatalf("sqliteRow(...): %v", err)
+	}
+	if got, want := strings.Count(sqliteInsert, "?"), len(row); got != want {
+		t.Fatalf("sqliteInsert has %d parameters, sqliteRow returned %d", got, want)
+	}
+
+	want := []any{
+		EventTypeResponse, ts.UnixNano(), "trace", "span", int32(2), int32(1), "patch", "function-patch",
+		"example.org/v1", "XBucket", "my-bucket", "default", "uid-1", "buckets",
+		"", "", "boom",
+	}
+	if diff := cmp.Diff(want, row[:len(row)-1]); diff != "" {
+		t.Errorf("sqliteRow(...) mismatch (-want +got):\n%s", diff)
+	}
+
+	got, err := DecodeSQLitePayload(row[len(row)-1].([]byte))
+	if err != nil {
+		t.Fatalf("DecodeSQLitePayload(...): %v", err)
+	}
+	if diff := cmp.Diff(any(payload), got); diff != "" {
+		t.Errorf("DecodeSQLitePayload(...) mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestSQLiteRowWithoutPayload(t *testing.T) {
+	row, err := sqliteRow(&Event{Type: EventTypeRequest})
+	if err != nil {
+		t.Fatalf("sqliteRow(...): %v", err)
+	}
+	if p := row[len(row)-1].([]byte); p != nil {
+		t.Errorf("sqliteRow(...): want nil payload, got %q", p)
+	}
+}
+
+func sqliteEvent(ts time.Time, name string, payload any) *Event {
+	return &Event{
+		Type: EventTypeResponse,
+		Meta: &pipelinev1alpha1.StepMeta{
+			Timestamp: timestamppb.New(ts),
+			Context: &pipelinev1alpha1.StepMeta_CompositionMeta{CompositionMeta: &pipelinev1alpha1.CompositionMeta{
+				CompositeResourceName: name,
+			}},
+		},
+		Payload: payload,
+	}
+}
+
+func TestSQLiteSinkWrite(t *testing.T) {
+	s, err := NewSQLiteSink(filepath.Join(t.TempDir(), "db", "events.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteSink(...): %v", err)
+	}
+	defer func() { _ = s.Close() }()
+
+	ts := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	payload := map[string]any{"results": []any{map[string]any{"message": "hello"}}}
+	for _, e := range []*Event{sqliteEvent(ts, "a", nil), sqliteEvent(ts, "b", payload)} {
+		if err := s.Write(e); err != nil {
+			t.Fatalf("Write(...): %v", err)
+		}
+	}
+	if err := s.Sync(); err != nil {
+		t.Errorf("Sync(): %v", err)
+	}
+	if err := s.Check(); err != nil {
+		t.Errorf("Check(): %v", err)
+	}
+
+	var (
+		typ       string
+		timestamp int64
+		blob      []byte
+	)
+	if err := s.db.QueryRow("SELECT type, timestamp, payload FROM events WHERE xr_name = ?", "b").Scan(&typ, &timestamp, &blob); err != nil {
+		t.Fatalf("SELECT: %v", err)
+	}
+	if typ != EventTypeResponse || timestamp != ts.UnixNano() {
+		t.Errorf("SELECT: want %s at %d, got %s at %d", EventTypeResponse, ts.UnixNano(), typ, timestamp)
+	}
+	got, err := DecodeSQLitePayload(blob)
+	if err != nil {
+		t.Fatalf("DecodeSQLitePayload(...): %v", err)
+	}
+	if diff := cmp.Diff(any(payload), got); diff != "" {
+		t.Errorf("DecodeSQLitePayload(...) mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestSQLiteSinkCompactRetention(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	s, err := NewSQLiteSink(filepath.Join(t.TempDir(), "events.db"), WithSQLiteRetention(time.Hour))
+	if err != nil {
+		t.Fatalf("NewSQLiteSink(...): %v", err)
+	}
+	defer func() { _ = s.Close() }()
+	s.now = func() time.Time { return now }
+
+	for _, e := range []*Event{
+		sqliteEvent(now.Add(-2*time.Hour), "expired", nil),
+		sqliteEvent(now.Add(-time.Minute), "kept", nil),
+	} {
+		if err := s.Write(e); err != nil {
+			t.Fatalf("Write(...): %v", err)
+		}
+	}
+	if err := s.Compact(context.Background()); err != nil {
+		t.Fatalf("Compact(...): %v", err)
+	}
+
+	if got := sqliteNames(t, s); !cmp.Equal(got, []string{"kept"}) {
+		t.Errorf("Compact(...): want only the recent event kept, got %v", got)
+	}
+}
+
+func TestSQLiteSinkCompactSize(t *testing.T) {
+	const maxSize = 256 << 10
+	s, err := NewSQLiteSink(filepath.Join(t.TempDir(), "events.db"), WithMaxSQLiteSize(maxSize))
+	if err != nil {
+		t.Fatalf("NewSQLiteSink(...): %v", err)
+	}
+	defer func() { _ = s.Close() }()
+
+	// Random payloads don't compress, so each event uses at least 1KiB.
+	r := rand.New(rand.NewPCG(1, 2)) //nolint:gosec // Doesn't need to be secure.
+	now := time.Now()
+	const events = 1000
+	for i := range events {
+		b := make([]byte, 1024)
+		for j := range b {
+			b[j] = byte(r.Uint32())
+		}
+		if err := s.Write(sqliteEvent(now, strconv.Itoa(i), base64.StdEncoding.EncodeToString(b))); err != nil {
+			t.Fatalf("Write(...): %v", err)
+		}
+	}
+	if err := s.Compact(context.Background()); err != nil {
+		t.Fatalf("Compact(...): %v", err)
+	}
+
+	var used int64
+	if err := s.db.QueryRow(sqliteUsedSize).Scan(&used); err != nil {
+		t.Fatalf("cannot measure database size: %v", err)
+	}
+	if used > maxSize {
+		t.Errorf("Compact(...): want database to use at most %d bytes, got %d", maxSize, used)
+	}
+	names := sqliteNames(t, s)
+	if len(names) == 0 || len(names) == events {
+		t.Fatalf("Compact(...): want some but not all events deleted, got %d events", len(names))
+	}
+	if names[len(names)-1] != strconv.Itoa(events-1) {
+		t.Errorf("Compact(...): want the newest event kept, got %s", names[len(names)-1])
+	}
+}
+
+// sqliteNames returns the XR names of the events in the supplied sink's
+// database, oldest first.
+func sqliteNames(t *testing.T, s *SQLiteSink) []string {
+	t.Helper()
+	rows, err := s.db.Query("SELECT xr_name FROM events ORDER BY id")
+	if err != nil {
+		t.Fatalf("SELECT: %v", err)
+	}
+	defer func() { _ = rows.Close() }()
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			t.Fatalf("Scan(...): %v", err)
+		}
+		names = append(names, name)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatalf("rows: %v", err)
+	}
+	return names
+}
@@ -0,0 +1,136 @@
+/*
+Copyright 2026 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+this file except in compliance with the License. You may obtain a copy of the
+License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed
+under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+CONDITIONS OF ANY KIND, either express or implied. See the License for the
+specific language governing permissions and limitations under the License.
+*/
+
+package server
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// A BroadcasterOption configures a Broadcaster.
+type BroadcasterOption func(*Broadcaster)
+
+// WithSubscriberBuffer sets the maximum number of events buffered for each
+// subscriber (default: 100).
+func WithSubscriberBuffer(n int) BroadcasterOption {
+	return func(b *Broadcaster) {
+		b.buffer = n
+	}
+}
+
+// A Broadcaster is a sink that delivers live events to any number of
+// subscribers. Each subscriber has its own bounded buffer. Writes never block;
+// events that don't fit in a subscriber's buffer are dropped for that
+// subscriber only.
+type Broadcaster struct {
+	buffer int
+
+	mu     sync.RWMutex
+	subs   map[*Subscription]struct{}
+	closed bool
+}
+
+// NewBroadcaster returns a sink that delivers events to subscribers.
+func NewBroadcaster(opts ...BroadcasterOption) *Broadcaster {
+	b := &Broadcaster{
+		buffer: 100,
+		subs:   make(map[*Subscription]struct{}),
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b
+}
+
+// A Subscription receives the live events that match its query.
+type Subscription struct {
+	b       *Broadcaster
+	q       Query
+	events  chan *Event
+	dropped atomic.Uint64
+	once    sync.Once
+}
+
+// Subscribe returns a subscription to events matching the supplied query. The
+// query's limit is ignored. Callers must close the subscription when done.
+func (b *Broadcaster) Subscribe(q Query) *Subscription {
+	s := &Subscription{b: b, q: q, events: make(chan *Event, max(b.buffer, 1))}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		s.once.Do(func() { close(s.events) })
+		return s
+	}
+	b.subs[s] = struct{}{}
+	return s
+}
+
+// Write delivers the supplied event to every subscriber whose query matches it.
+func (b *Broadcaster) Write(e *Event) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for s := range b.subs {
+		if !s.q.Match(e) {
+			continue
+		}
+		select {
+		case s.events <- e:
+		default:
+			s.dropped.Add(1)
+		}
+	}
+	return nil
+}
+
+// Subscribers returns the number of open subscriptions.
+func (b *Broadcaster) Subscribers() int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return len(b.subs)
+}
+
+// Close ends all subscriptions, and any made in future.
+func (b *Broadcaster) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	for s := range b.subs {
+		delete(b.subs, s)
+		s.once.Do(func() { close(s.events) })
+	}
+	return nil
+}
+
+// Events returns a channel of matching events. The channel is closed when the
+// subscription or its broadcaster is closed.
+func (s *Subscription) Events() <-chan *Event {
+	return s.events
+}
+
+// Dropped returns the number of matching events dropped because the
+// subscription's buffer was full.
+func (s *Subscription) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+// Close ends the subscription.
+func (s *Subscription) Close() {
+	s.b.mu.Lock()
+	defer s.b.mu.Unlock()
+	delete(s.b.subs, s)
+	s.once.Do(func() { close(s.events) })
+}
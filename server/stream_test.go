@@ -0,0 +1,82 @@
+/*
+Copyright 2026 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+this file except in compliance with the License. You may obtain a copy of the
+License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed
+under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+CONDITIONS OF ANY KIND, either express or implied. See the License for the
+specific language governing permissions and limitations under the License.
+*/
+
+package server
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	pipelinev1alpha1 "github.com/crossplane/crossplane-runtime/v2/apis/pipelineinspector/proto/v1alpha1"
+)
+
+func receive(s *Subscription) []string {
+	var got []string
+	for {
+		select {
+		case e, ok := <-s.Events():
+			if !ok {
+				return got
+			}
+			got = append(got, e.Meta.GetSpanId())
+		default:
+			return got
+		}
+	}
+}
+
+func TestBroadcaster(t *testing.T) {
+	b := NewBroadcaster(WithSubscriberBuffer(2))
+	all := b.Subscribe(Query{})
+	fnA := b.Subscribe(Query{FunctionName: "function-a"})
+
+	for i, fn := range []string{"function-a", "function-b", "function-a"} {
+		e := &Event{Type: EventTypeRequest, Meta: &pipelinev1alpha1.StepMeta{SpanId: string(rune('0' + i)), FunctionName: fn}}
+		if err := b.Write(e); err != nil {
+			t.Fatalf("Write() failed: %v", err)
+		}
+	}
+
+	// The unfiltered subscriber's buffer filled up, so its third event was
+	// dropped without blocking the writer or the other subscriber.
+	if diff := cmp.Diff([]string{"0", "1"}, receive(all)); diff != "" {
+		t.Errorf("all: events mismatch (-want +got):\n%s", diff)
+	}
+	if got := all.Dropped(); got != 1 {
+		t.Errorf("all.Dropped(): want 1, got %d", got)
+	}
+	if diff := cmp.Diff([]string{"0", "2"}, receive(fnA)); diff != "" {
+		t.Errorf("fnA: events mismatch (-want +got):\n%s", diff)
+	}
+	if got := fnA.Dropped(); got != 0 {
+		t.Errorf("fnA.Dropped(): want 0, got %d", got)
+	}
+
+	all.Close()
+	all.Close() // Closing twice is safe.
+	if got := b.Subscribers(); got != 1 {
+		t.Errorf("Subscribers(): want 1, got %d", got)
+	}
+
+	_ = b.Close()
+	if _, ok := <-fnA.Events(); ok {
+		t.Error("fnA.Events(): want closed channel after broadcaster Close()")
+	}
+	fnA.Close()
+	if _, ok := <-b.Subscribe(Query{}).Events(); ok {
+		t.Error("Subscribe(): want closed channel after broadcaster Close()")
+	}
+}
@@ -0,0 +1,338 @@
+/*
+Copyright 2026 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+this file except in compliance with the License. You may obtain a copy of the
+License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed
+under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+CONDITIONS OF ANY KIND, either express or implied. See the License for the
+specific language governing permissions and limitations under the License.
+*/
+
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+	"text/tabwriter"
+)
+
+// Kinds of change to a desired resource listed by a summary.
+const (
+	changeAdded   = "ADDED"
+	changeRemoved = "REMOVED"
+	changeChanged = "CHANGED"
+)
+
+// A SummarySink writes each event to a writer as a compact, human-readable
+// summary. Rather than the full payload it writes the number of observed and
+// desired resources, the resources a step added, removed or changed, and the
+// results, conditions, requirements and TTL a function returned.
+type SummarySink struct {
+	out io.Writer
+}
+
+// NewSummarySink returns a sink that writes summaries to the supplied writer.
+// Each event is rendered to a buffer and written with a single call to a
+// SyncWriter, so events written concurrently never interleave.
+func NewSummarySink(w io.Writer) *SummarySink {
+	return &SummarySink{out: NewSyncWriter(w)}
+}
+
+// Write the supplied event as a summary.
+func (s *SummarySink) Write(e *Event) error {
+	buf := &bytes.Buffer{}
+	renderSummary(buf, e)
+	_, err := s.out.Write(buf.Bytes())
+	return err
+}
+
+// renderSummary renders the supplied event as a summary.
+func renderSummary(buf *bytes.Buffer, e *Event) {
+	meta := e.Meta
+	_, _ = fmt.Fprintf(buf, "=== %s ===\n", e.Type)
+	renderContext(buf, meta)
+	_, _ = fmt.Fprintf(buf, "  Step:        %s (index %d, iteration %d)\n", meta.GetStepName(), meta.GetStepIndex(), meta.GetIteration())
+	_, _ = fmt.Fprintf(buf, "  Function:    %s\n", meta.GetFunctionName())
+	if e.Error != "" {
+		_, _ = fmt.Fprintf(buf, "  Error:       %s\n", e.Error)
+	}
+
+	switch p := e.Payload.(type) {
+	case *Step:
+		renderStepSummary(buf, p)
+	case *Pipeline:
+		renderPipelineSummary(buf, len(p.Steps), p.Desired)
+	case *Drift:
+		if p.Diff != nil {
+			renderChanges(buf, p.Diff)
+		}
+	case *PayloadSummary:
+		_, _ = fmt.Fprintf(buf, "  Observed:    %d resources\n", len(p.Observed))
+		_, _ = fmt.Fprintf(buf, "  Desired:     %d resources\n", len(p.Desired))
+		_, _ = fmt.Fprintf(buf, "  Results:     %d\n", p.Results)
+	case map[string]any:
+		renderDecodedSummary(buf, e.Type, p)
+	}
+	buf.WriteString("\n")
+}
+
+// renderDecodedSummary renders a summary of the supplied payload, decoded
+// from JSON, for example by the tail command.
+func renderDecodedSummary(buf *bytes.Buffer, typ string, p map[string]any) {
+	switch typ {
+	case EventTypeStep:
+		step := &Step{Request: p["request"], Response: p["response"]}
+		renderStepSummary(buf, step)
+		// A step with both a request and a response was diffed above.
+		if patch, ok := p["diff"].([]any); ok && (step.Request == nil || step.Response == nil) {
+			renderPatchChanges(buf, patch)
+		}
+	case EventTypePipeline:
+		renderPipelineSummary(buf, len(asSlice(p["steps"])), p["desired"])
+	case EventTypeNondeterminism, EventTypeDrift:
+		if patch, ok := p["diff"].([]any); ok {
+			renderPatchChanges(buf, patch)
+		}
+	default:
+		if typ == EventTypeRequest {
+			_, _ = fmt.Fprintf(buf, "  Observed:    %d resources\n", len(resourceNames(p["observed"])))
+		}
+		_, _ = fmt.Fprintf(buf, "  Desired:     %d resources\n", len(resourceNames(p["desired"])))
+		if typ == EventTypeResponse {
+			renderResponseSummary(buf, p)
+		}
+	}
+}
+
+// renderPipelineSummary renders a summary of a pipeline with the supplied
+// number of steps and final desired state.
+func renderPipelineSummary(buf *bytes.Buffer, steps int, desired any) {
+	_, _ = fmt.Fprintf(buf, "  Steps:       %d\n", steps)
+	_, _ = fmt.Fprintf(buf, "  Desired:     %d resources\n", len(resourceNames(desired)))
+}
+
+// renderStepSummary renders a summary of the supplied step. A step summarized
+// from its diff alone only lists the resources it changed.
+func renderStepSummary(buf *bytes.Buffer, step *Step) {
+	if step.Request != nil {
+		_, _ = fmt.Fprintf(buf, "  Observed:    %d resources\n", len(resourceNames(field(step.Request, "observed"))))
+	}
+	if step.Response != nil {
+		_, _ = fmt.Fprintf(buf, "  Desired:     %d resources\n", len(resourceNames(field(step.Response, "desired"))))
+	}
+
+	diff := step.Diff
+	if diff == nil && step.Request != nil && step.Response != nil {
+		diff = NewStepDiff(step.Request, step.Response)
+	}
+	if diff != nil {
+		renderChanges(buf, diff)
+	}
+	if step.Response != nil {
+		renderResponseSummary(buf, step.Response)
+	}
+}
+
+// renderChanges renders a table of the desired resources added, removed or
+// changed by the supplied diff.
+func renderChanges(buf *bytes.Buffer, d *StepDiff) {
+	var rows [][]string
+	if fxr, txr := d.from["composite"], d.to["composite"]; !reflect.DeepEqual(fxr, txr) {
+		res := field(txr, "resource")
+		rows = append(rows, []string{changeChanged, stringField(field(res, "metadata"), "name") + " (XR)", gvk(res)})
+	}
+
+	from, _ := d.from["resources"].(map[string]any)
+	to, _ := d.to["resources"].(map[string]any)
+	names := slices.Collect(maps.Keys(from))
+	for name := range to {
+		if _, ok := from[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		f, inFrom := from[name]
+		t, inTo := to[name]
+		switch {
+		case !inTo:
+			rows = append(rows, []string{changeRemoved, name, gvk(field(f, "resource"))})
+		case !inFrom:
+			rows = append(rows, []string{changeAdded, name, gvk(field(t, "resource"))})
+		case !reflect.DeepEqual(f, t):
+			rows = append(rows, []string{changeChanged, name, gvk(field(t, "resource"))})
+		}
+	}
+
+	if len(rows) == 0 {
+		buf.WriteString("  Changes:     none\n")
+		return
+	}
+	renderTable(buf, "Changes", rows)
+}
+
+// renderPatchChanges renders a table of the desired resources added, removed
+// or changed by the supplied decoded StepDiff patch. A patch doesn't include
+// the values of removed resources, so they're listed without a kind.
+func renderPatchChanges(buf *bytes.Buffer, patch []any) {
+	unescape := strings.NewReplacer("~1", "/", "~0", "~")
+	xr := false
+	changes := map[string][]string{}
+	for _, op := range patch {
+		o, v := stringField(op, "op"), field(op, "value")
+		path := strings.Split(strings.TrimPrefix(stringField(op, "path"), "/desired/"), "/")
+		switch {
+		case path[0] == "composite":
+			xr = true
+		case path[0] != "resources":
+			continue
+		case len(path) == 1 && o == PatchOpRemove:
+			// All resources were removed.
+			changes["*"] = []string{changeRemoved, "*", ""}
+		case len(path) == 1:
+			resources, _ := v.(map[string]any)
+			for name, r := range resources {
+				changes[name] = []string{changeAdded, name, gvk(field(r, "resource"))}
+			}
+		case len(path) == 2:
+			name := unescape.Replace(path[1])
+			switch o {
+			case PatchOpAdd:
+				changes[name] = []string{changeAdded, name, gvk(field(v, "resource"))}
+			case PatchOpRemove:
+				changes[name] = []string{changeRemoved, name, ""}
+			default:
+				changes[name] = []string{changeChanged, name, gvk(field(v, "resource"))}
+			}
+		default:
+			name := unescape.Replace(path[1])
+			if _, ok := changes[name]; !ok {
+				changes[name] = []string{changeChanged, name, ""}
+			}
+		}
+	}
+
+	var rows [][]string
+	if xr {
+		rows = append(rows, []string{changeChanged, "(XR)", ""})
+	}
+	for _, name := range slices.Sorted(maps.Keys(changes)) {
+		rows = append(rows, changes[name])
+	}
+	if len(rows) == 0 {
+		buf.WriteString("  Changes:     none\n")
+		return
+	}
+	renderTable(buf, "Changes", rows)
+}
+
+// renderResponseSummary renders the results, conditions, requirements and TTL
+// of the supplied decoded RunFunctionResponse.
+func renderResponseSummary(buf *bytes.Buffer, rsp any) {
+	var results [][]string
+	for _, r := range asSlice(field(rsp, "results")) {
+		results = append(results, []string{enumName(field(r, "severity"), "SEVERITY_"), stringField(r, "message")})
+	}
+	renderTable(buf, "Results", results)
+
+	var conditions [][]string
+	for _, c := range asSlice(field(rsp, "conditions")) {
+		conditions = append(conditions, []string{
+			stringField(c, "type"),
+			enumName(field(c, "status"), "STATUS_CONDITION_"),
+			stringField(c, "reason"),
+			stringField(c, "message"),
+		})
+	}
+	renderTable(buf, "Conditions", conditions)
+
+	var requirements [][]string
+	reqs := field(rsp, "requirements")
+	for _, kind := range []string{"extraResources", "resources"} {
+		selectors, _ := field(reqs, kind).(map[string]any)
+		for _, name := range slices.Sorted(maps.Keys(selectors)) {
+			sel := selectors[name]
+			requirements = append(requirements, []string{name, gvk(sel), selectorMatch(sel)})
+		}
+	}
+	renderTable(buf, "Requirements", requirements)
+
+	if ttl := stringField(field(rsp, "meta"), "ttl"); ttl != "" {
+		_, _ = fmt.Fprintf(buf, "  TTL:         %s\n", ttl)
+	}
+}
+
+// renderTable renders the supplied rows as an indented table with the
+// supplied heading. It renders nothing if there are no rows.
+func renderTable(buf *bytes.Buffer, heading string, rows [][]string) {
+	if len(rows) == 0 {
+		return
+	}
+	_, _ = fmt.Fprintf(buf, "  %s:\n", heading)
+	table := &bytes.Buffer{}
+	tw := tabwriter.NewWriter(table, 0, 0, 2, ' ', 0)
+	for _, row := range rows {
+		_, _ = fmt.Fprintf(tw, "    %s\n", strings.Join(row, "\t"))
+	}
+	_ = tw.Flush()
+
+	// Rows may have empty trailing cells, which are padded.
+	for _, line := range splitLines(table.String()) {
+		buf.WriteString(strings.TrimRight(line, " \n") + "\n")
+	}
+}
+
+// resourceNames returns the names of the composed resources in the supplied
+// decoded observed or desired state.
+func resourceNames(state any) []string {
+	resources, _ := field(state, "resources").(map[string]any)
+	return slices.Sorted(maps.Keys(resources))
+}
+
+// gvk returns the apiVersion and kind of the supplied decoded resource or
+// resource selector, for example example.org/v1/XBucket.
+func gvk(v any) string {
+	return stringField(v, "apiVersion") + "/" + stringField(v, "kind")
+}
+
+// selectorMatch describes how the supplied decoded resource selector matches
+// resources, for example name=my-config or labels=app=web.
+func selectorMatch(sel any) string {
+	var match []string
+	if ns := stringField(sel, "namespace"); ns != "" {
+		match = append(match, "namespace="+ns)
+	}
+	if name := stringField(sel, "matchName"); name != "" {
+		match = append(match, "name="+name)
+	}
+	labels, _ := field(field(sel, "matchLabels"), "labels").(map[string]any)
+	if len(labels) > 0 {
+		pairs := make([]string, 0, len(labels))
+		for _, k := range slices.Sorted(maps.Keys(labels)) {
+			pairs = append(pairs, fmt.Sprintf("%s=%v", k, labels[k]))
+		}
+		match = append(match, "labels="+strings.Join(pairs, ","))
+	}
+	return strings.Join(match, " ")
+}
+
+// enumName returns the supplied decoded enum value without the supplied
+// prefix, for example FATAL for SEVERITY_FATAL. Values that aren't names, such
+// as numbers, are formatted as is.
+func enumName(v any, prefix string) string {
+	s, ok := v.(string)
+	if !ok {
+		return fmt.Sprint(v)
+	}
+	return strings.TrimPrefix(s, prefix)
+}
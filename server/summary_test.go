@@ -0,0 +1,140 @@
+/*
+Copyright 2026 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+this file except in compliance with the License. You may obtain a copy of the
+License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed
+under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+CONDITIONS OF ANY KIND, either express or implied. See the License for the
+specific language governing permissions and limitations under the License.
+*/
+
+package server
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	pipelinev1alpha1 "github.com/crossplane/crossplane-runtime/v2/apis/pipelineinspector/proto/v1alpha1"
+)
+
+func TestSummarySink(t *testing.T) {
+	meta := &pipelinev1alpha1.StepMeta{
+		StepName:     "patch",
+		StepIndex:    1,
+		FunctionName: "function-patch-and-transform",
+		Context: &pipelinev1alpha1.StepMeta_CompositionMeta{CompositionMeta: &pipelinev1alpha1.CompositionMeta{
+			CompositeResourceApiVersion: "example.org/v1",
+			CompositeResourceKind:       "XBucket",
+			CompositeResourceName:       "my-bucket",
+			CompositeResourceUid:        "uid-1",
+			CompositionName:             "buckets",
+		}},
+	}
+	resource := func(kind, region string) map[string]any {
+		return map[string]any{"resource": map[string]any{"apiVersion": "s3.example.org/v1", "kind": kind, "spec": map[string]any{"region": region}}}
+	}
+	req := map[string]any{
+		"observed": map[string]any{"resources": map[string]any{"bucket": resource("Bucket", "us-east-1")}},
+		"desired": map[string]any{"resources": map[string]any{
+			"bucket": resource("Bucket", "us-east-1"),
+			"policy": resource("BucketPolicy", "us-east-1"),
+		}},
+	}
+	rsp := map[string]any{
+		"meta": map[string]any{"tag": "abc", "ttl": "60s"},
+		"desired": map[string]any{"resources": map[string]any{
+			"bucket": resource("Bucket", "us-west-2"),
+			"acl":    resource("BucketACL", "us-west-2"),
+		}},
+		"results": []any{
+			map[string]any{"severity": "SEVERITY_WARNING", "message": "region changed"},
+		},
+		"conditions": []any{
+			map[string]any{"type": "BucketReady", "status": "STATUS_CONDITION_FALSE", "reason": "Creating"},
+		},
+		"requirements": map[string]any{"extraResources": map[string]any{
+			"config": map[string]any{"apiVersion": "v1", "kind": "ConfigMap", "namespace": "default", "matchName": "bucket-config"},
+			"zones":  map[string]any{"apiVersion": "example.org/v1", "kind": "Zone", "matchLabels": map[string]any{"labels": map[string]any{"region": "us-west-2"}}},
+		}},
+	}
+
+	tests := []struct {
+		name string
+		e    *Event
+		want string
+	}{
+		{
+			name: "step",
+			e:    &Event{Type: EventTypeStep, Meta: meta, Payload: &Step{Request: req, Response: rsp}},
+			want: `=== STEP ===
+  XR:          example.org/v1/XBucket (my-bucket)
+  XR UID:      uid-1
+  Composition: buckets
+  Step:        patch (index 1, iteration 0)
+  Function:    function-patch-and-transform
+  Observed:    1 resources
+  Desired:     2 resources
+  Changes:
+    ADDED    acl     s3.example.org/v1/BucketACL
+    CHANGED  bucket  s3.example.org/v1/Bucket
+    REMOVED  policy  s3.example.org/v1/BucketPolicy
+  Results:
+    WARNING  region changed
+  Conditions:
+    BucketReady  FALSE  Creating
+  Requirements:
+    config  v1/ConfigMap         namespace=default name=bucket-config
+    zones   example.org/v1/Zone  labels=region=us-west-2
+  TTL:         60s
+
+`,
+		},
+		{
+			name: "request",
+			e:    &Event{Type: EventTypeRequest, Meta: meta, Payload: req, Error: "boom"},
+			want: `=== REQUEST ===
+  XR:          example.org/v1/XBucket (my-bucket)
+  XR UID:      uid-1
+  Composition: buckets
+  Step:        patch (index 1, iteration 0)
+  Function:    function-patch-and-transform
+  Error:       boom
+  Observed:    1 resources
+  Desired:     2 resources
+
+`,
+		},
+		{
+			name: "unchanged step diff",
+			e:    &Event{Type: EventTypeStep, Meta: meta, Payload: &Step{Diff: NewStepDiff(req, req)}},
+			want: `=== STEP ===
+  XR:          example.org/v1/XBucket (my-bucket)
+  XR UID:      uid-1
+  Composition: buckets
+  Step:        patch (index 1, iteration 0)
+  Function:    function-patch-and-transform
+  Changes:     none
+
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			if err := NewSummarySink(buf).Write(tt.e); err != nil {
+				t.Fatalf("Write(...): %v", err)
+			}
+			if diff := cmp.Diff(tt.want, buf.String()); diff != "" {
+				t.Errorf("Write(...) mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
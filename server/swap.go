@@ -0,0 +1,97 @@
+/*
+Copyright 2026 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+this file except in compliance with the License. You may obtain a copy of the
+License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed
+under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+CONDITIONS OF ANY KIND, either express or implied. See the License for the
+specific language governing permissions and limitations under the License.
+*/
+
+package server
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// A SwappableSink writes events to a sink that can be replaced while events
+// are being written, for example when configuration is reloaded.
+type SwappableSink struct {
+	mu   sync.RWMutex
+	sink Sink
+}
+
+// NewSwappableSink returns a sink that writes events to the supplied sink until
+// it's swapped for another.
+func NewSwappableSink(s Sink) *SwappableSink {
+	return &SwappableSink{sink: s}
+}
+
+// Swap the current sink for the supplied sink, returning the previous one.
+// Swap waits for in-flight writes to the previous sink to finish, so it can be
+// closed as soon as Swap returns.
+func (s *SwappableSink) Swap(next Sink) Sink {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	prev := s.sink
+	s.sink = next
+	return prev
+}
+
+// Write the supplied event to the current sink.
+func (s *SwappableSink) Write(e *Event) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.sink.Write(e)
+}
+
+// Close the current sink if it implements io.Closer.
+func (s *SwappableSink) Close() error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return CloseSink(s.sink)
+}
+
+// Sync the current sink if it has a Sync method.
+func (s *SwappableSink) Sync() error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return syncSink(s.sink)
+}
+
+// Check whether the current sink can write events, if it has a Check method.
+func (s *SwappableSink) Check() error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return checkSink(s.sink)
+}
+
+// A SwappableFilter matches events using a filter that can be replaced while
+// events are being matched.
+type SwappableFilter struct {
+	filter atomic.Pointer[Filter]
+}
+
+// NewSwappableFilter returns a filter that matches events using the supplied
+// filter until it's swapped for another.
+func NewSwappableFilter(f Filter) *SwappableFilter {
+	s := &SwappableFilter{}
+	s.Swap(f)
+	return s
+}
+
+// Swap the current filter for the supplied filter.
+func (s *SwappableFilter) Swap(f Filter) {
+	s.filter.Store(&f)
+}
+
+// Match the supplied event using the current filter.
+func (s *SwappableFilter) Match(e *Event) bool {
+	return (*s.filter.Load()).Match(e)
+}
@@ -0,0 +1,90 @@
+/*
+Copyright 2026 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+this file except in compliance with the License. You may obtain a copy of the
+License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed
+under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+CONDITIONS OF ANY KIND, either express or implied. See the License for the
+specific language governing permissions and limitations under the License.
+*/
+
+package server
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSwappableSink(t *testing.T) {
+	var first, second []string
+	record := func(to *[]string) Sink {
+		return SinkFunc(func(e *Event) error {
+			*to = append(*to, e.Type)
+			return nil
+		})
+	}
+
+	s := NewSwappableSink(record(&first))
+	_ = s.Write(&Event{Type: EventTypeRequest})
+	if prev := s.Swap(record(&second)); prev == nil {
+		t.Error("Swap(...): want previous sink, got nil")
+	}
+	_ = s.Write(&Event{Type: EventTypeResponse})
+
+	if diff := cmp.Diff([]string{EventTypeRequest}, first); diff != "" {
+		t.Errorf("first sink (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{EventTypeResponse}, second); diff != "" {
+		t.Errorf("second sink (-want +got):\n%s", diff)
+	}
+}
+
+func TestSwappableSinkConcurrentSwap(t *testing.T) {
+	var mu sync.Mutex
+	n := 0
+	count := SinkFunc(func(*Event) error {
+		mu.Lock()
+		defer mu.Unlock()
+		n++
+		return nil
+	})
+
+	s := NewSwappableSink(count)
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 100 {
+				_ = s.Write(&Event{})
+			}
+		}()
+	}
+	for range 10 {
+		s.Swap(count)
+	}
+	wg.Wait()
+
+	if n != 400 {
+		t.Errorf("want 400 events written, got %d", n)
+	}
+}
+
+func TestSwappableFilter(t *testing.T) {
+	f := NewSwappableFilter(MatchEventTypes(EventTypeRequest))
+	e := &Event{Type: EventTypeResponse}
+	if f.Match(e) {
+		t.Error("Match(RESPONSE): want false before swap")
+	}
+	f.Swap(MatchEventTypes(EventTypeResponse))
+	if !f.Match(e) {
+		t.Error("Match(RESPONSE): want true after swap")
+	}
+}
@@ -0,0 +1,256 @@
+/*
+Copyright 2026 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+this file except in compliance with the License. You may obtain a copy of the
+License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed
+under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+CONDITIONS OF ANY KIND, either express or implied. See the License for the
+specific language governing permissions and limitations under the License.
+*/
+
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// cutMarkerBytes is roughly how many bytes the markers added by cutValue take.
+const cutMarkerBytes = 64
+
+// A PayloadSummary replaces a payload that is larger than a TruncatingSink
+// allows.
+type PayloadSummary struct {
+	// Truncated is always true. It distinguishes summaries from payloads.
+	Truncated bool `json:"truncated"`
+
+	// Size of the payload's JSON encoding in bytes.
+	Size int `json:"size"`
+
+	// SHA256 of the payload's JSON encoding, hex encoded.
+	SHA256 string `json:"sha256"`
+
+	// Observed and Desired list the composite and composed resources in the
+	// payload's observed and desired state.
+	Observed []ResourceRef `json:"observed,omitempty"`
+	Desired  []ResourceRef `json:"desired,omitempty"`
+
+	// Results is the number of results in the payload.
+	Results int `json:"results"`
+}
+
+// A ResourceRef identifies a resource in a summarized payload.
+type ResourceRef struct {
+	// Name of the composed resource, or of the composite resource if
+	// Composite is true.
+	Name string `json:"name"`
+
+	// APIVersion and Kind of the resource, if known.
+	APIVersion string `json:"apiVersion,omitempty"`
+	Kind       string `json:"kind,omitempty"`
+
+	// Composite is true if this is the composite resource.
+	Composite bool `json:"composite,omitempty"`
+}
+
+// A TruncatingSink replaces payloads larger than a limit with a summary before
+// writing events to another sink. The events it receives are never modified,
+// so they may also be written in full to other sinks.
+type TruncatingSink struct {
+	wrappedSink
+
+	maxBytes int
+}
+
+// NewTruncatingSink returns a sink that summarizes payloads whose JSON
+// encoding is larger than the supplied number of bytes.
+func NewTruncatingSink(s Sink, maxBytes int) *TruncatingSink {
+	return &TruncatingSink{wrappedSink: wrappedSink{sink: s}, maxBytes: maxBytes}
+}
+
+// Write the supplied event, summarizing its payload if it's too large. The
+// request and response of a STEP event, and the desired state of a PIPELINE
+// event, are summarized individually. Other large payloads, and the diffs of
+// STEP events, are cut instead: they're written as generic JSON, with long
+// strings shortened, and array items and object values that don't fit replaced
+// with markers.
+func (s *TruncatingSink) Write(e *Event) error {
+	switch p := e.Payload.(type) {
+	case *Step:
+		req, reqOK := s.truncate(p.Request)
+		rsp, rspOK := s.truncate(p.Response)
+		if reqOK || rspOK {
+			step := *p
+			step.Request, step.Response = req, rsp
+			e = withPayload(e, &step)
+		}
+		if p.Diff == nil {
+			break
+		}
+		if d, ok := s.cut(p.Diff.Patch); ok {
+			var step map[string]any
+			if j, err := json.Marshal(e.Payload); err == nil && json.Unmarshal(j, &step) == nil {
+				step["diff"] = d
+				e = withPayload(e, step)
+			}
+		}
+	case *Pipeline:
+		if d, ok := s.truncate(p.Desired); ok {
+			pl := *p
+			pl.Desired = d
+			e = withPayload(e, &pl)
+		}
+	case map[string]any:
+		if t, ok := s.truncate(p); ok {
+			e = withPayload(e, t)
+		}
+	default:
+		if c, ok := s.cut(p); ok {
+			e = withPayload(e, c)
+		}
+	}
+	return s.sink.Write(e)
+}
+
+// truncate returns a summary of the supplied payload and true if the payload is
+// too large. Otherwise it returns the payload and false.
+func (s *TruncatingSink) truncate(payload any) (any, bool) {
+	if payload == nil {
+		return nil, false
+	}
+	j, err := json.Marshal(payload)
+	if err != nil || len(j) <= s.maxBytes {
+		return payload, false
+	}
+	return SummarizePayload(payload, j), true
+}
+
+// cut returns the supplied payload, decoded as generic JSON and cut to fit the
+// limit, and true if the payload is too large. Otherwise it returns the payload
+// and false.
+func (s *TruncatingSink) cut(payload any) (any, bool) {
+	if payload == nil {
+		return nil, false
+	}
+	j, err := json.Marshal(payload)
+	if err != nil || len(j) <= s.maxBytes {
+		return payload, false
+	}
+	var v any
+	if err := json.Unmarshal(j, &v); err != nil {
+		return payload, false
+	}
+	return cutValue(v, s.maxBytes), true
+}
+
+// cutValue cuts the supplied decoded JSON value so that its JSON encoding is
+// roughly no larger than the supplied number of bytes. Strings are shortened,
+// array items that don't fit are replaced with a single marker, and object
+// values that don't fit are replaced with markers.
+func cutValue(v any, limit int) any {
+	switch x := v.(type) {
+	case string:
+		if len(x) <= limit {
+			return x
+		}
+		keep := max(limit-cutMarkerBytes, 0)
+		return strings.ToValidUTF8(x[:keep], "") + fmt.Sprintf("...[%d bytes truncated]", len(x)-keep)
+	case []any:
+		out := make([]any, 0, len(x))
+		for i, item := range x {
+			c := cutValue(item, limit)
+			n := jsonSize(c)
+			if n > limit {
+				return append(out, fmt.Sprintf("[%d items truncated]", len(x)-i))
+			}
+			out = append(out, c)
+			limit -= n
+		}
+		return out
+	case map[string]any:
+		out := make(map[string]any, len(x))
+		for _, k := range slices.Sorted(maps.Keys(x)) {
+			c := cutValue(x[k], limit-len(k))
+			if n := jsonSize(c) + len(k); n <= limit {
+				out[k], limit = c, limit-n
+				continue
+			}
+			out[k] = fmt.Sprintf("[%d bytes truncated]", jsonSize(x[k]))
+		}
+		return out
+	}
+	return v
+}
+
+// jsonSize returns the size of the JSON encoding of the supplied value.
+func jsonSize(v any) int {
+	j, _ := json.Marshal(v)
+	return len(j)
+}
+
+// withPayload returns a shallow copy of the supplied event with a new payload.
+func withPayload(e *Event, payload any) *Event {
+	c := *e
+	c.Payload = payload
+	return &c
+}
+
+// SummarizePayload summarizes the supplied decoded RunFunctionRequest or
+// RunFunctionResponse, given its JSON encoding.
+func SummarizePayload(payload any, j []byte) *PayloadSummary {
+	sum := sha256.Sum256(j)
+	return &PayloadSummary{
+		Truncated: true,
+		Size:      len(j),
+		SHA256:    hex.EncodeToString(sum[:]),
+		Observed:  resourceRefs(field(payload, "observed")),
+		Desired:   resourceRefs(field(payload, "desired")),
+		Results:   len(asSlice(field(payload, "results"))),
+	}
+}
+
+// resourceRefs lists the resources in the supplied observed or desired state.
+// Composed resources are sorted by name, after the composite resource.
+func resourceRefs(state any) []ResourceRef {
+	var refs []ResourceRef
+	if xr, ok := field(state, "composite").(map[string]any); ok {
+		res := xr["resource"]
+		refs = append(refs, ResourceRef{
+			Name:       stringField(field(res, "metadata"), "name"),
+			APIVersion: stringField(res, "apiVersion"),
+			Kind:       stringField(res, "kind"),
+			Composite:  true,
+		})
+	}
+
+	resources, _ := field(state, "resources").(map[string]any)
+	for _, name := range slices.Sorted(maps.Keys(resources)) {
+		res := field(resources[name], "resource")
+		refs = append(refs, ResourceRef{
+			Name:       name,
+			APIVersion: stringField(res, "apiVersion"),
+			Kind:       stringField(res, "kind"),
+		})
+	}
+	return refs
+}
+
+func stringField(v any, name string) string {
+	s, _ := field(v, name).(string)
+	return s
+}
+
+func asSlice(v any) []any {
+	s, _ := v.([]any)
+	return s
+}
@@ -0,0 +1,152 @@
+/*
+Copyright 2026 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+this file except in compliance with the License. You may obtain a copy of the
+License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed
+under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+CONDITIONS OF ANY KIND, either express or implied. See the License for the
+specific language governing permissions and limitations under the License.
+*/
+
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestTruncatingSink(t *testing.T) {
+	rsp := decode(t, `{
+		"desired": {
+			"composite": {"resource": {"apiVersion": "example.org/v1", "kind": "XDatabase", "metadata": {"name": "my-db"}}},
+			"resources": {
+				"instance": {"resource": {"apiVersion": "sql.example.org/v1", "kind": "Instance", "spec": {"big": "`+strings.Repeat("x", 256)+`"}}},
+				"bucket": {"resource": {"apiVersion": "storage.example.org/v1", "kind": "Bucket"}}
+			}
+		},
+		"results": [{"severity": "SEVERITY_NORMAL", "message": "ok"}, {"severity": "SEVERITY_WARNING", "message": "hmm"}]
+	}`)
+	j, _ := json.Marshal(rsp)
+	sum := sha256.Sum256(j)
+
+	wantSummary := &PayloadSummary{
+		Truncated: true,
+		Size:      len(j),
+		SHA256:    hex.EncodeToString(sum[:]),
+		Desired: []ResourceRef{
+			{Name: "my-db", APIVersion: "example.org/v1", Kind: "XDatabase", Composite: true},
+			{Name: "bucket", APIVersion: "storage.example.org/v1", Kind: "Bucket"},
+			{Name: "instance", APIVersion: "sql.example.org/v1", Kind: "Instance"},
+		},
+		Results: 2,
+	}
+	small := map[string]any{"input": "small"}
+
+	tests := []struct {
+		name  string
+		event *Event
+		want  any
+	}{
+		{
+			name:  "small payload unchanged",
+			event: &Event{Type: EventTypeRequest, Payload: small},
+			want:  small,
+		},
+		{
+			name:  "large payload summarized",
+			event: &Event{Type: EventTypeResponse, Payload: rsp},
+			want:  wantSummary,
+		},
+		{
+			name:  "large step response summarized",
+			event: &Event{Type: EventTypeStep, Payload: &Step{Request: small, Response: rsp}},
+			want:  &Step{Request: small, Response: wantSummary},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got *Event
+			s := NewTruncatingSink(SinkFunc(func(e *Event) error {
+				got = e
+				return nil
+			}), 128)
+			orig := tt.event.Payload
+
+			if err := s.Write(tt.event); err != nil {
+				t.Fatalf("Write() failed: %v", err)
+			}
+			if diff := cmp.Diff(tt.want, got.Payload); diff != "" {
+				t.Errorf("payload mismatch (-want +got):\n%s", diff)
+			}
+			// The original event must not be modified, so other sinks can
+			// still write the full payload.
+			if diff := cmp.Diff(orig, tt.event.Payload); diff != "" {
+				t.Errorf("original payload modified (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestTruncatingSink_Cut(t *testing.T) {
+	big := strings.Repeat("x", 1024)
+	desired := map[string]any{"resources": map[string]any{
+		"a": map[string]any{"resource": map[string]any{"spec": map[string]any{"big": big}}},
+		"b": map[string]any{"resource": map[string]any{"spec": map[string]any{"big": big}}},
+	}}
+
+	tests := []struct {
+		name    string
+		payload any
+		want    []string
+	}{
+		{
+			name:    "drift diff",
+			payload: &Drift{PreviousTraceID: "trace-1", OutputHash: "abc", Diff: NewDesiredDiff(nil, desired)},
+			want:    []string{`"previousTraceId":"trace-1"`, `"outputHash":"abc"`, "truncated]"},
+		},
+		{
+			name:    "step diff",
+			payload: &Step{Diff: NewDesiredDiff(nil, desired)},
+			want:    []string{`"diff":[`, "truncated]"},
+		},
+		{
+			name:    "string",
+			payload: big,
+			want:    []string{"xxx", "bytes truncated]"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got *Event
+			s := NewTruncatingSink(SinkFunc(func(e *Event) error {
+				got = e
+				return nil
+			}), 256)
+
+			if err := s.Write(&Event{Type: EventTypeDrift, Payload: tt.payload}); err != nil {
+				t.Fatalf("Write() failed: %v", err)
+			}
+			j, _ := json.Marshal(got.Payload)
+			if len(j) > 256+cutMarkerBytes {
+				t.Errorf("expected payload to be cut to about 256 bytes, got %d: %s", len(j), j)
+			}
+			for _, w := range tt.want {
+				if !strings.Contains(string(j), w) {
+					t.Errorf("expected payload to contain %q, got: %s", w, j)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,378 @@
+/*
+Copyright 2026 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+this file except in compliance with the License. You may obtain a copy of the
+License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed
+under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+CONDITIONS OF ANY KIND, either express or implied. See the License for the
+specific language governing permissions and limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/crossplane/crossplane-runtime/v2/pkg/logging"
+	"github.com/crossplane/inspector-sidecar/server"
+)
+
+// Sink kinds that can be configured using the --sink flag.
+const (
+	sinkKindStdout = "stdout"
+	sinkKindStderr = "stderr"
+	sinkKindFile   = "file"
+	sinkKindSQLite = "sqlite"
+
+	sinkKindOTLPTraces = "otlp-traces"
+	sinkKindOTLPLogs   = "otlp-logs"
+)
+
+// eventTypes are the event types sinks can be configured to match.
+var eventTypes = []string{
+	server.EventTypeRequest,
+	server.EventTypeResponse,
+	server.EventTypeStep,
+	server.EventTypePipeline,
+	server.EventTypeNondeterminism,
+	server.EventTypeDrift,
+}
+
+// A sinkSpec describes a sink configured using the --sink flag. Specs take the
+// form KIND[,KEY=VALUE...], for example:
+//
+//	stdout,format=text
+//	file,format=json,path=/var/log/inspector/events.log,event=RESPONSE
+//	file,path=/var/log/inspector/events.log,max-size=100Mi,max-total-size=1Gi,compress=true
+//	file,path=/var/log/inspector/archive.log,archive=true
+//	sqlite,path=/var/lib/inspector/events.db,retention=168h,max-total-size=1Gi
+//	stdout,filter=payload.results.exists(r, r.severity == "SEVERITY_FATAL")
+//	otlp-traces,endpoint=otel-collector:4317,insecure=true
+//	otlp-logs,protocol=http/protobuf,endpoint=https://otel-collector:4318/v1/logs
+//
+// The event key may be repeated to match several event types. The filter key
+// is a CEL expression that may contain commas, so it must be the last key.
+type sinkSpec struct {
+	Kind   string
+	Format string
+	Path   string
+	Events []string
+
+	// Filter is a CEL expression events must match.
+	Filter string
+
+	// Archive sinks receive full payloads, regardless of --max-payload-bytes.
+	Archive bool
+
+	// Rotation and retention options for file sinks.
+	MaxSize      int64
+	MaxAge       time.Duration
+	Retention    time.Duration
+	MaxTotalSize int64
+	Compress     bool
+
+	// OTLP is where OTLP sinks send telemetry.
+	OTLP server.OTLPEndpoint
+}
+
+// parseSinkSpec parses the supplied --sink flag value. Specs that don't set a
+// format use the supplied default format.
+func parseSinkSpec(s, defaultFormat string) (sinkSpec, error) {
+	parts := strings.Split(s, ",")
+	spec := sinkSpec{Kind: parts[0], Format: defaultFormat}
+
+options:
+	for i, p := range parts[1:] {
+		k, v, ok := strings.Cut(p, "=")
+		if !ok {
+			return sinkSpec{}, fmt.Errorf("invalid sink option %q: must be KEY=VALUE", p)
+		}
+		switch k {
+		case "filter":
+			spec.Filter = strings.Join(append([]string{v}, parts[i+2:]...), ",")
+			break options
+		case "format":
+			spec.Format = v
+		case "path":
+			spec.Path = v
+		case "event":
+			spec.Events = append(spec.Events, strings.ToUpper(v))
+		case "max-size", "max-total-size":
+			n, err := parseByteSize(v)
+			if err != nil {
+				return sinkSpec{}, fmt.Errorf("invalid %s: %w", k, err)
+			}
+			if k == "max-size" {
+				spec.MaxSize = n
+			} else {
+				spec.MaxTotalSize = n
+			}
+		case "max-age", "retention":
+			d, err := time.ParseDuration(v)
+			if err != nil {
+				return sinkSpec{}, fmt.Errorf("invalid %s: %w", k, err)
+			}
+			if k == "max-age" {
+				spec.MaxAge = d
+			} else {
+				spec.Retention = d
+			}
+		case "compress", "archive", "insecure":
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				return sinkSpec{}, fmt.Errorf("invalid %s: %w", k, err)
+			}
+			switch k {
+			case "compress":
+				spec.Compress = b
+			case "archive":
+				spec.Archive = b
+			default:
+				spec.OTLP.Insecure = b
+			}
+		case "endpoint":
+			spec.OTLP.Endpoint = v
+		case "protocol":
+			spec.OTLP.Protocol = v
+		default:
+			return sinkSpec{}, fmt.Errorf("unknown sink option %q", k)
+		}
+	}
+
+	if !slices.Contains([]string{sinkKindStdout, sinkKindStderr, sinkKindFile, sinkKindSQLite, sinkKindOTLPTraces, sinkKindOTLPLogs}, spec.Kind) {
+		return sinkSpec{}, fmt.Errorf("unknown sink kind %q: must be one of stdout, stderr, file, sqlite, otlp-traces or otlp-logs", spec.Kind)
+	}
+	if !slices.Contains([]string{server.FormatJSON, server.FormatText, server.FormatSummary}, spec.Format) {
+		return sinkSpec{}, fmt.Errorf("unknown sink format %q: must be one of json, text or summary", spec.Format)
+	}
+	if (spec.Kind == sinkKindFile || spec.Kind == sinkKindSQLite) && spec.Path == "" {
+		return sinkSpec{}, fmt.Errorf("%s sinks require a path option", spec.Kind)
+	}
+	if spec.Kind != sinkKindFile && (spec.MaxSize != 0 || spec.MaxAge != 0 || spec.Compress) {
+		return sinkSpec{}, errors.New("rotation options are only supported by file sinks")
+	}
+	if spec.Kind != sinkKindFile && spec.Kind != sinkKindSQLite && (spec.Retention != 0 || spec.MaxTotalSize != 0) {
+		return sinkSpec{}, errors.New("retention options are only supported by file and sqlite sinks")
+	}
+	if spec.OTLP != (server.OTLPEndpoint{}) && spec.Kind != sinkKindOTLPTraces && spec.Kind != sinkKindOTLPLogs {
+		return sinkSpec{}, errors.New("endpoint, protocol and insecure options are only supported by OTLP sinks")
+	}
+	if err := spec.OTLP.Validate(); err != nil {
+		return sinkSpec{}, err
+	}
+	for _, e := range spec.Events {
+		if !slices.Contains(eventTypes, e) {
+			return sinkSpec{}, fmt.Errorf("unknown event type %q", e)
+		}
+	}
+
+	return spec, nil
+}
+
+// key identifies the sink described by the spec. Specs with the same key
+// describe the same sink.
+func (s sinkSpec) key() string {
+	return fmt.Sprintf("%#v", s)
+}
+
+// sinkDeps are the dependencies of the sinks built from --sink flag values.
+type sinkDeps struct {
+	// metrics counts write errors, if set.
+	metrics *server.Metrics
+
+	// log reports errors sinks can't return, such as failing to rotate a
+	// file, if set.
+	log logging.Logger
+
+	// stepTimeout is how long sinks that pair steps wait for a response, if
+	// set.
+	stepTimeout time.Duration
+}
+
+// Sinks that write to stdout or stderr share a writer, so that events written
+// by different sinks never interleave.
+var (
+	stdout = server.NewSyncWriter(os.Stdout)
+	stderr = server.NewSyncWriter(os.Stderr)
+)
+
+// buildSink builds the sink described by the supplied spec, without its
+// event type and CEL filters.
+func buildSink(spec sinkSpec, deps sinkDeps) (server.Sink, error) {
+	var s server.Sink
+	switch spec.Kind {
+	case sinkKindStdout:
+		s = server.NewFormatSink(spec.Format, stdout)
+	case sinkKindStderr:
+		s = server.NewFormatSink(spec.Format, stderr)
+	case sinkKindFile:
+		opts := []server.FileOption{
+			server.WithMaxFileSize(spec.MaxSize),
+			server.WithMaxFileAge(spec.MaxAge),
+			server.WithFileRetention(spec.Retention),
+			server.WithMaxTotalFileSize(spec.MaxTotalSize),
+			server.WithFileCompression(spec.Compress),
+		}
+		if deps.log != nil {
+			opts = append(opts, server.WithFileLogger(deps.log))
+		}
+		fs, err := server.NewFileSink(spec.Path, spec.Format, opts...)
+		if err != nil {
+			return nil, fmt.Errorf("cannot open sink file: %w", err)
+		}
+		s = fs
+	case sinkKindSQLite:
+		ss, err := server.NewSQLiteSink(spec.Path,
+			server.WithSQLiteRetention(spec.Retention),
+			server.WithMaxSQLiteSize(spec.MaxTotalSize),
+		)
+		if err != nil {
+			return nil, fmt.Errorf("cannot open sink database: %w", err)
+		}
+		s = ss
+	case sinkKindOTLPTraces:
+		exp, err := server.NewOTLPSpanExporter(context.Background(), spec.OTLP)
+		if err != nil {
+			return nil, fmt.Errorf("cannot create OTLP exporter: %w", err)
+		}
+		// Spans need paired steps. Events that are already paired pass
+		// through unchanged.
+		var opts []server.PairingOption
+		if deps.stepTimeout > 0 {
+			opts = append(opts, server.WithStepTimeout(deps.stepTimeout))
+		}
+		if deps.log != nil {
+			opts = append(opts, server.WithPairingLogger(deps.log))
+		}
+		s = server.NewPairingSink(server.NewSpanSink(exp), opts...)
+	case sinkKindOTLPLogs:
+		exp, err := server.NewOTLPLogExporter(context.Background(), spec.OTLP)
+		if err != nil {
+			return nil, fmt.Errorf("cannot create OTLP exporter: %w", err)
+		}
+		s = server.NewLogSink(exp)
+	}
+	return s, nil
+}
+
+// buildSinks builds a sink that fans out to all of the supplied --sink flag
+// values, and to any supplied extra sinks. It writes to stdout in the default
+// format if no values are supplied. If maxPayloadBytes is positive, larger
+// payloads are summarized for all sinks except archive sinks. Sinks with a CEL
+// filter evaluate it before payloads are summarized, so expressions always see
+// the full payload.
+//
+// Sinks in the supplied reuse map, keyed by spec, are reused rather than
+// built again. buildSinks returns the sink built or reused for each value,
+// keyed by spec.
+func buildSinks(values []string, defaultFormat string, maxPayloadBytes int, deps sinkDeps, reuse map[string]server.Sink, extra ...server.Sink) (server.Sink, map[string]server.Sink, error) {
+	if len(values) == 0 {
+		values = []string{sinkKindStdout}
+	}
+
+	// Sinks are truncated together, unless they're archives or have a CEL
+	// filter. Other sinks are written full events.
+	sinks := make([]server.Sink, 0, len(values)+len(extra))
+	sinks = append(sinks, extra...)
+	var full []server.Sink
+	built := make(map[string]server.Sink, len(values))
+	var opened []server.Sink
+	closeAll := func() {
+		_ = server.NewFanOutSink(opened...).Close()
+	}
+	for _, v := range values {
+		spec, err := parseSinkSpec(v, defaultFormat)
+		if err != nil {
+			closeAll()
+			return nil, nil, fmt.Errorf("invalid sink %q: %w", v, err)
+		}
+		// Compile the filter first, so we don't open a file we won't use.
+		var filter *server.CELFilter
+		if spec.Filter != "" {
+			if filter, err = server.NewCELFilter(spec.Filter); err != nil {
+				closeAll()
+				return nil, nil, fmt.Errorf("cannot build sink %q: %w", v, err)
+			}
+		}
+		key := spec.key()
+		s, ok := built[key]
+		if !ok {
+			s, ok = reuse[key]
+		}
+		if !ok {
+			s, err = buildSink(spec, deps)
+			if err != nil {
+				closeAll()
+				return nil, nil, fmt.Errorf("cannot build sink %q: %w", v, err)
+			}
+			s = deps.metrics.InstrumentSink(spec.Kind, s)
+			opened = append(opened, s)
+		}
+		built[key] = s
+
+		if len(spec.Events) > 0 {
+			s = server.NewFilteredSink(s, server.MatchEventTypes(spec.Events...))
+		}
+		// Spans don't include payloads, but need full responses to find
+		// fatal results.
+		truncate := maxPayloadBytes > 0 && !spec.Archive && spec.Kind != sinkKindOTLPTraces
+		switch {
+		case filter != nil:
+			if truncate {
+				s = server.NewTruncatingSink(s, maxPayloadBytes)
+			}
+			full = append(full, server.NewFilteredSink(s, filter))
+		case truncate:
+			sinks = append(sinks, s)
+		default:
+			full = append(full, s)
+		}
+	}
+
+	if maxPayloadBytes <= 0 {
+		return server.NewFanOutSink(append(sinks, full...)...), built, nil
+	}
+	return server.NewFanOutSink(append(full, server.NewTruncatingSink(server.NewFanOutSink(sinks...), maxPayloadBytes))...), built, nil
+}
+
+// byteSizeSuffixes are the binary suffixes accepted by parseByteSize.
+var byteSizeSuffixes = []struct {
+	suffix string
+	mult   int64
+}{
+	{"Ki", 1 << 10},
+	{"Mi", 1 << 20},
+	{"Gi", 1 << 30},
+}
+
+// parseByteSize parses a number of bytes, optionally suffixed with Ki, Mi or
+// Gi, for example 100Mi.
+func parseByteSize(s string) (int64, error) {
+	mult := int64(1)
+	for _, bs := range byteSizeSuffixes {
+		if strings.HasSuffix(s, bs.suffix) {
+			s, mult = strings.TrimSuffix(s, bs.suffix), bs.mult
+			break
+		}
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	if n < 0 {
+		return 0, errors.New("must not be negative")
+	}
+	return n * mult, nil
+}
@@ -0,0 +1,318 @@
+/*
+Copyright 2026 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+this file except in compliance with the License. You may obtain a copy of the
+License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed
+under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+CONDITIONS OF ANY KIND, either express or implied. See the License for the
+specific language governing permissions and limitations under the License.
+*/
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	pipelinev1alpha1 "github.com/crossplane/crossplane-runtime/v2/apis/pipelineinspector/proto/v1alpha1"
+	"github.com/crossplane/inspector-sidecar/server"
+)
+
+func TestParseSinkSpec(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    string
+		want    sinkSpec
+		wantErr bool
+	}{
+		{
+			name: "kind only uses default format",
+			spec: "stdout",
+			want: sinkSpec{Kind: "stdout", Format: "json"},
+		},
+		{
+			name: "format override",
+			spec: "stderr,format=text",
+			want: sinkSpec{Kind: "stderr", Format: "text"},
+		},
+		{
+			name: "file with repeated events",
+			spec: "file,path=/tmp/events.log,event=request,event=RESPONSE",
+			want: sinkSpec{Kind: "file", Format: "json", Path: "/tmp/events.log", Events: []string{"REQUEST", "RESPONSE"}},
+		},
+		{
+			name: "file with rotation",
+			spec: "file,path=/tmp/events.log,max-size=100Mi,max-age=1h,retention=168h,max-total-size=1Gi,compress=true",
+			want: sinkSpec{
+				Kind:         "file",
+				Format:       "json",
+				Path:         "/tmp/events.log",
+				MaxSize:      100 << 20,
+				MaxAge:       time.Hour,
+				Retention:    168 * time.Hour,
+				MaxTotalSize: 1 << 30,
+				Compress:     true,
+			},
+		},
+		{
+			name: "archive",
+			spec: "file,path=/tmp/archive.log,archive=true",
+			want: sinkSpec{Kind: "file", Format: "json", Path: "/tmp/archive.log", Archive: true},
+		},
+		{
+			name: "filter with commas",
+			spec: `stdout,event=RESPONSE,filter=payload.results.exists(r, r.severity == "SEVERITY_FATAL")`,
+			want: sinkSpec{Kind: "stdout", Format: "json", Events: []string{"RESPONSE"}, Filter: `payload.results.exists(r, r.severity == "SEVERITY_FATAL")`},
+		},
+		{
+			name: "sqlite with retention",
+			spec: "sqlite,path=/var/lib/inspector/events.db,retention=168h,max-total-size=1Gi",
+			want: sinkSpec{Kind: "sqlite", Format: "json", Path: "/var/lib/inspector/events.db", Retention: 168 * time.Hour, MaxTotalSize: 1 << 30},
+		},
+		{
+			name:    "sqlite without path",
+			spec:    "sqlite",
+			wantErr: true,
+		},
+		{
+			name:    "sqlite rotation",
+			spec:    "sqlite,path=/var/lib/inspector/events.db,max-size=1Mi",
+			wantErr: true,
+		},
+		{
+			name:    "retention on stdout",
+			spec:    "stdout,retention=1h",
+			wantErr: true,
+		},
+		{
+			name: "otlp traces",
+			spec: "otlp-traces,endpoint=otel-collector:4317,insecure=true",
+			want: sinkSpec{Kind: "otlp-traces", Format: "json", OTLP: server.OTLPEndpoint{Endpoint: "otel-collector:4317", Insecure: true}},
+		},
+		{
+			name: "otlp traces over http",
+			spec: "otlp-traces,protocol=http/protobuf,endpoint=https://otel-collector:4318/v1/traces",
+			want: sinkSpec{Kind: "otlp-traces", Format: "json", OTLP: server.OTLPEndpoint{Protocol: "http/protobuf", Endpoint: "https://otel-collector:4318/v1/traces"}},
+		},
+		{
+			name: "otlp logs",
+			spec: "otlp-logs,event=RESPONSE,endpoint=otel-collector:4317",
+			want: sinkSpec{Kind: "otlp-logs", Format: "json", Events: []string{"RESPONSE"}, OTLP: server.OTLPEndpoint{Endpoint: "otel-collector:4317"}},
+		},
+		{
+			name:    "unknown otlp protocol",
+			spec:    "otlp-traces,protocol=thrift",
+			wantErr: true,
+		},
+		{
+			name:    "endpoint on stdout",
+			spec:    "stdout,endpoint=otel-collector:4317",
+			wantErr: true,
+		},
+		{
+			name:    "invalid archive",
+			spec:    "stdout,archive=maybe",
+			wantErr: true,
+		},
+		{
+			name:    "rotation on stdout",
+			spec:    "stdout,max-size=1Mi",
+			wantErr: true,
+		},
+		{
+			name:    "invalid max size",
+			spec:    "file,path=/tmp/events.log,max-size=lots",
+			wantErr: true,
+		},
+		{
+			name:    "unknown kind",
+			spec:    "kafka",
+			wantErr: true,
+		},
+		{
+			name:    "unknown format",
+			spec:    "stdout,format=yaml",
+			wantErr: true,
+		},
+		{
+			name:    "unknown option",
+			spec:    "stdout,color=true",
+			wantErr: true,
+		},
+		{
+			name:    "malformed option",
+			spec:    "stdout,format",
+			wantErr: true,
+		},
+		{
+			name:    "file without path",
+			spec:    "file",
+			wantErr: true,
+		},
+		{
+			name:    "unknown event type",
+			spec:    "stdout,event=BOGUS",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseSinkSpec(tt.spec, "json")
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("parseSinkSpec(%q): expected error, got %+v", tt.spec, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseSinkSpec(%q) failed: %v", tt.spec, err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("parseSinkSpec(%q) mismatch (-want +got):\n%s", tt.spec, diff)
+			}
+		})
+	}
+}
+
+func TestBuildSinks(t *testing.T) {
+	dir := t.TempDir()
+	jsonPath := filepath.Join(dir, "events.json")
+	textPath := filepath.Join(dir, "responses.txt")
+
+	sink, _, err := buildSinks([]string{
+		"file,path=" + jsonPath,
+		"file,format=text,event=RESPONSE,path=" + textPath,
+	}, "json", 0, sinkDeps{}, nil)
+	if err != nil {
+		t.Fatalf("buildSinks failed: %v", err)
+	}
+
+	meta := &pipelinev1alpha1.StepMeta{FunctionName: "my-function"}
+	for _, typ := range []string{server.EventTypeRequest, server.EventTypeResponse} {
+		if err := sink.Write(&server.Event{Type: typ, Meta: meta}); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+	}
+	if err := server.NewInspector("json", server.WithSink(sink)).Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	jsonOut, err := os.ReadFile(jsonPath)
+	if err != nil {
+		t.Fatalf("cannot read JSON sink file: %v", err)
+	}
+	if got := strings.Count(string(jsonOut), "\n"); got != 2 {
+		t.Errorf("expected 2 JSON lines, got %d: %s", got, jsonOut)
+	}
+
+	textOut, err := os.ReadFile(textPath)
+	if err != nil {
+		t.Fatalf("cannot read text sink file: %v", err)
+	}
+	if strings.Contains(string(textOut), "=== REQUEST ===") || !strings.Contains(string(textOut), "=== RESPONSE ===") {
+		t.Errorf("expected only RESPONSE events in text sink, got: %s", textOut)
+	}
+}
+
+func TestBuildSinks_MaxPayloadBytes(t *testing.T) {
+	dir := t.TempDir()
+	eventsPath := filepath.Join(dir, "events.json")
+	archivePath := filepath.Join(dir, "archive.json")
+	filteredPath := filepath.Join(dir, "filtered.json")
+
+	sink, _, err := buildSinks([]string{
+		"file,path=" + eventsPath,
+		"file,archive=true,path=" + archivePath,
+		"file,path=" + filteredPath + ",filter=size(payload.input) > 100",
+	}, "json", 64, sinkDeps{}, nil)
+	if err != nil {
+		t.Fatalf("buildSinks failed: %v", err)
+	}
+
+	big := map[string]any{"input": strings.Repeat("x", 128)}
+	if err := sink.Write(&server.Event{Type: server.EventTypeRequest, Meta: &pipelinev1alpha1.StepMeta{}, Payload: big}); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if err := server.NewInspector("json", server.WithSink(sink)).Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	events, err := os.ReadFile(eventsPath)
+	if err != nil {
+		t.Fatalf("cannot read events file: %v", err)
+	}
+	if !strings.Contains(string(events), `"truncated":true`) || strings.Contains(string(events), "xxxx") {
+		t.Errorf("expected a summarized payload, got: %s", events)
+	}
+
+	// The filter sees the full payload, but the sink gets a summary.
+	filtered, err := os.ReadFile(filteredPath)
+	if err != nil {
+		t.Fatalf("cannot read filtered file: %v", err)
+	}
+	if !strings.Contains(string(filtered), `"truncated":true`) {
+		t.Errorf("expected a summarized payload to pass the filter, got: %q", filtered)
+	}
+
+	archive, err := os.ReadFile(archivePath)
+	if err != nil {
+		t.Fatalf("cannot read archive file: %v", err)
+	}
+	if strings.Contains(string(archive), `"truncated":true`) || !strings.Contains(string(archive), strings.Repeat("x", 128)) {
+		t.Errorf("expected the full payload in the archive, got: %s", archive)
+	}
+}
+
+func TestBuildSink_InvalidFilter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.json")
+	if _, _, err := buildSinks([]string{"file,path=" + path + ",filter=payload.results.exists("}, "json", 0, sinkDeps{}, nil); err == nil {
+		t.Fatal("buildSinks: expected error for invalid filter")
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("expected sink file not to be created, got %v", err)
+	}
+}
+
+func TestParseByteSize(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    int64
+		wantErr bool
+	}{
+		{in: "1024", want: 1024},
+		{in: "4Ki", want: 4096},
+		{in: "100Mi", want: 100 << 20},
+		{in: "2Gi", want: 2 << 30},
+		{in: "10MB", wantErr: true},
+		{in: "-1", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := parseByteSize(tt.in)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("parseByteSize(%q): expected error, got %d", tt.in, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseByteSize(%q) failed: %v", tt.in, err)
+			}
+			if got != tt.want {
+				t.Errorf("parseByteSize(%q) = %d, want %d", tt.in, got, tt.want)
+			}
+		})
+	}
+}
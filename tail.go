@@ -0,0 +1,99 @@
+/*
+Copyright 2026 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+this file except in compliance with the License. You may obtain a copy of the
+License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed
+under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+CONDITIONS OF ANY KIND, either express or implied. See the License for the
+specific language governing permissions and limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/crossplane/inspector-sidecar/server"
+)
+
+// TailCmd prints events captured by a running inspector sidecar.
+type TailCmd struct {
+	Address     string `default:"http://localhost:8080"                                     env:"INSPECTOR_ADDRESS"  help:"Address of the sidecar's query API, e.g. http://localhost:8080 or unix:///path/to/socket."`
+	Format      string `default:"text"                                                      enum:"json,text,summary" help:"Output format (json, text or summary)."`
+	Type        string `help:"Only print events of this type, e.g. RESPONSE."`
+	XR          string `help:"Only print events for the composite resource with this name." name:"xr"`
+	XRUID       string `help:"Only print events for the composite resource with this UID."  name:"xr-uid"`
+	Composition string `help:"Only print events for pipelines defined by this Composition."`
+	Operation   string `help:"Only print events for pipelines defined by this Operation."`
+	Function    string `help:"Only print events for calls to this function."`
+	Trace       string `help:"Only print events for the pipeline run with this trace ID."`
+	Since       string `help:"Only print events newer than this duration (e.g. 5m) or RFC 3339 timestamp."`
+	Limit       int    `help:"Only print this many of the newest buffered events."`
+	Follow      bool   `help:"Keep printing live events as they are captured."              short:"f"`
+
+	out io.Writer
+}
+
+// Run prints the matching events.
+func (c *TailCmd) Run() error {
+	q, err := c.query(time.Now())
+	if err != nil {
+		return err
+	}
+
+	out := c.out
+	if out == nil {
+		out = os.Stdout
+	}
+	sink := server.NewFormatSink(c.Format, out)
+	client := server.NewClient(c.Address)
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	if c.Follow {
+		return client.Stream(ctx, q, sink.Write)
+	}
+
+	events, err := client.List(ctx, q)
+	if err != nil {
+		return err
+	}
+	for _, e := range events {
+		if err := sink.Write(e); err != nil {
+			return fmt.Errorf("cannot write event: %w", err)
+		}
+	}
+	return nil
+}
+
+// query returns the query selecting the events to print.
+func (c *TailCmd) query(now time.Time) (server.Query, error) {
+	since, err := server.ParseQueryTime(c.Since, now)
+	if err != nil {
+		return server.Query{}, fmt.Errorf("invalid --since: %w", err)
+	}
+	return server.Query{
+		Type:            strings.ToUpper(c.Type),
+		XRName:          c.XR,
+		XRUID:           c.XRUID,
+		CompositionName: c.Composition,
+		OperationName:   c.Operation,
+		FunctionName:    c.Function,
+		TraceID:         c.Trace,
+		Since:           since,
+		Limit:           c.Limit,
+	}, nil
+}
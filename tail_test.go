@@ -0,0 +1,189 @@
+/*
+Copyright 2026 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+this file except in compliance with the License. You may obtain a copy of the
+License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed
+under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+CONDITIONS OF ANY KIND, either express or implied. See the License for the
+specific language governing permissions and limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pipelinev1alpha1 "github.com/crossplane/crossplane-runtime/v2/apis/pipelineinspector/proto/v1alpha1"
+	"github.com/crossplane/inspector-sidecar/server"
+)
+
+func TestTailCmdQuery(t *testing.T) {
+	now := time.Date(2026, 1, 15, 10, 30, 0, 0, time.UTC)
+
+	c := &TailCmd{
+		Type:        "response",
+		XR:          "my-xr",
+		XRUID:       "uid-1",
+		Composition: "my-composition",
+		Operation:   "my-op",
+		Function:    "function-a",
+		Trace:       "trace-1",
+		Since:       "5m",
+		Limit:       10,
+	}
+	got, err := c.query(now)
+	if err != nil {
+		t.Fatalf("query() failed: %v", err)
+	}
+	want := server.Query{
+		Type:            "RESPONSE",
+		XRName:          "my-xr",
+		XRUID:           "uid-1",
+		CompositionName: "my-composition",
+		OperationName:   "my-op",
+		FunctionName:    "function-a",
+		TraceID:         "trace-1",
+		Since:           now.Add(-5 * time.Minute),
+		Limit:           10,
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("query() mismatch (-want +got):\n%s", diff)
+	}
+
+	if _, err := (&TailCmd{Since: "yesterday"}).query(now); err == nil {
+		t.Error("query(): expected error for invalid --since")
+	}
+}
+
+func TestTailCmdRun(t *testing.T) {
+	r := server.NewRingBuffer(10, 0)
+	for _, fn := range []string{"function-a", "function-b"} {
+		_ = r.Write(&server.Event{
+			Type: server.EventTypeRequest,
+			Meta: &pipelinev1alpha1.StepMeta{
+				StepName:     "step",
+				FunctionName: fn,
+				TraceId:      "trace-1",
+				SpanId:       fn,
+				Timestamp:    timestamppb.New(time.Date(2026, 1, 15, 10, 30, 0, 0, time.UTC)),
+			},
+			Payload: map[string]any{"key": "value"},
+		})
+	}
+	srv := httptest.NewServer(server.NewEventsHandler(r))
+	defer srv.Close()
+
+	out := &bytes.Buffer{}
+	c := &TailCmd{Address: srv.URL, Format: server.FormatText, Function: "function-b", out: out}
+	if err := c.Run(); err != nil {
+		t.Fatalf("Run() failed: %v", err)
+	}
+
+	want := `=== REQUEST ===
+  Step:        step (index 0, iteration 0)
+  Function:    function-b
+  Trace ID:    trace-1
+  Span ID:     function-b
+  Timestamp:   2026-01-15T10:30:00.000Z
+  Payload:
+    key: value
+
+
+`
+	if diff := cmp.Diff(want, out.String()); diff != "" {
+		t.Errorf("Run() output mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestTailCmdRun_Summary(t *testing.T) {
+	resource := func(kind, region string) map[string]any {
+		return map[string]any{"resource": map[string]any{"apiVersion": "s3.example.org/v1", "kind": kind, "spec": map[string]any{"region": region}}}
+	}
+	req := map[string]any{
+		"observed": map[string]any{"resources": map[string]any{"bucket": resource("Bucket", "us-east-1")}},
+		"desired": map[string]any{"resources": map[string]any{
+			"bucket": resource("Bucket", "us-east-1"),
+			"policy": resource("BucketPolicy", "us-east-1"),
+		}},
+	}
+	rsp := map[string]any{
+		"desired": map[string]any{"resources": map[string]any{
+			"bucket": resource("Bucket", "us-west-2"),
+			"acl":    resource("BucketACL", "us-west-2"),
+		}},
+		"results": []any{map[string]any{"severity": "SEVERITY_WARNING", "message": "region changed"}},
+	}
+
+	// Events are marshalled to JSON by the query API, and decoded by the
+	// tail command, so their payloads are no longer typed.
+	r := server.NewRingBuffer(10, 0)
+	for _, e := range []struct {
+		typ     string
+		payload any
+	}{
+		{server.EventTypeStep, &server.Step{Request: req, Response: rsp}},
+		{server.EventTypeStep, &server.Step{Diff: server.NewStepDiff(req, rsp)}},
+		{server.EventTypePipeline, &server.Pipeline{Steps: []server.PipelineStep{{StepName: "patch"}}, Desired: rsp["desired"]}},
+		{server.EventTypeDrift, &server.Drift{Diff: server.NewDesiredDiff(req["desired"], rsp["desired"])}},
+	} {
+		_ = r.Write(&server.Event{Type: e.typ, Meta: &pipelinev1alpha1.StepMeta{StepName: "patch", FunctionName: "function-a"}, Payload: e.payload})
+	}
+	srv := httptest.NewServer(server.NewEventsHandler(r))
+	defer srv.Close()
+
+	out := &bytes.Buffer{}
+	c := &TailCmd{Address: srv.URL, Format: server.FormatSummary, out: out}
+	if err := c.Run(); err != nil {
+		t.Fatalf("Run() failed: %v", err)
+	}
+
+	want := `=== STEP ===
+  Step:        patch (index 0, iteration 0)
+  Function:    function-a
+  Observed:    1 resources
+  Desired:     2 resources
+  Changes:
+    ADDED    acl     s3.example.org/v1/BucketACL
+    CHANGED  bucket  s3.example.org/v1/Bucket
+    REMOVED  policy  s3.example.org/v1/BucketPolicy
+  Results:
+    WARNING  region changed
+
+=== STEP ===
+  Step:        patch (index 0, iteration 0)
+  Function:    function-a
+  Changes:
+    ADDED    acl     s3.example.org/v1/BucketACL
+    CHANGED  bucket
+    REMOVED  policy
+
+=== PIPELINE ===
+  Step:        patch (index 0, iteration 0)
+  Function:    function-a
+  Steps:       1
+  Desired:     2 resources
+
+=== DRIFT ===
+  Step:        patch (index 0, iteration 0)
+  Function:    function-a
+  Changes:
+    ADDED    acl     s3.example.org/v1/BucketACL
+    CHANGED  bucket
+    REMOVED  policy
+
+`
+	if diff := cmp.Diff(want, out.String()); diff != "" {
+		t.Errorf("Run() output mismatch (-want +got):\n%s", diff)
+	}
+}